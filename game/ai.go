@@ -0,0 +1,386 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultAIDepth is used when AIOptions.Depth is left unset
+const DefaultAIDepth = 2
+
+const (
+	// Score of a won position, larger than any evaluation of a position that is still being played
+	winScore = 1 << 40
+	infinity = 2 * winScore
+	// Worth of a captured pair in PENTE games
+	captureScore = 1 << 10
+)
+
+// Built-in strengths of the AI
+type AILevel int
+
+const (
+	EASY AILevel = iota
+	MEDIUM
+	HARD
+)
+
+var AILevels = []AILevel{EASY, MEDIUM, HARD}
+
+func (l AILevel) String() string {
+	return []string{"EASY", "MEDIUM", "HARD"}[l]
+}
+
+// Returns the options of the AI playing at the level
+func (l AILevel) Options() AIOptions {
+	switch l {
+	case EASY:
+		return AIOptions{Depth: 1}
+	case MEDIUM:
+		return AIOptions{Depth: 4, TimeLimit: time.Second}
+	default:
+		return AIOptions{TimeLimit: 3 * time.Second}
+	}
+}
+
+type AIOptions struct {
+	// Maximum number of moves the AI looks ahead, counting both its own and the opponent's. Left
+	// unset with a TimeLimit the AI looks as far ahead as it has time for.
+	Depth int
+	// Time the AI may spend on a move, unlimited when left unset
+	TimeLimit time.Duration
+	// Number of searched positions the AI remembers, kept between moves so that an AI should only
+	// play one game
+	TableSize int
+	// Distance from the stones on the board within which the AI tries moves
+	Radius int
+}
+
+// Computer opponent that searches the moves ahead with negamax and alpha-beta pruning
+type LocalAI struct {
+	opts AIOptions
+	// Number of moves made during the current search
+	nodes int
+	// Set when the search was cancelled, the scores of the unfinished depth are then thrown away
+	stopped bool
+	// Set when the search cut off a line that was still being played at the depth limit, otherwise
+	// searching deeper would give the same result
+	horizon bool
+	// Copy of a flat bounded board that the search keeps in step with its moves to score lines faster
+	bits  *bitBoard
+	table *transpositionTable
+	last  SearchResult
+}
+
+// Result of a search for the best move
+type SearchResult struct {
+	Move Move
+	// Score of the move for the player making it, see winScore for the scores of won games
+	Score int
+	// Deepest search that was completed
+	Depth int
+	// Number of moves made over all the depths searched
+	Nodes int
+	Table TableStats
+}
+
+func NewLocalAI(opts AIOptions) *LocalAI {
+	if opts.Depth == 0 && opts.TimeLimit == 0 {
+		opts.Depth = DefaultAIDepth
+	}
+	if opts.TableSize == 0 {
+		opts.TableSize = DefaultTableSize
+	}
+	if opts.Radius == 0 {
+		opts.Radius = DefaultRadius
+	}
+	return &LocalAI{opts: opts, table: newTranspositionTable(opts.TableSize)}
+}
+
+// Returns the result of the last search
+func (ai *LocalAI) LastSearch() SearchResult {
+	return ai.last
+}
+
+// Returns the move the AI would make for the player whose turn it is
+func (ai *LocalAI) ChooseMove(g *GameState) (Move, error) {
+	result, err := ai.Search(context.Background(), g)
+	return result.Move, err
+}
+
+// Searches the moves of the player whose turn it is one depth deeper at a time until the depth
+// limit or the time limit is reached or ctx is cancelled. Returns the best move of the deepest search
+// that was completed, the first depth is always completed so that there is a move to return.
+func (ai *LocalAI) Search(ctx context.Context, g *GameState) (SearchResult, error) {
+	if !g.isTurn() {
+		return SearchResult{}, errors.New("it isn't anyone's turn")
+	} else if g.opts.Players > 2 {
+		return SearchResult{}, errors.New("AI can only play two player games")
+	}
+	result, ok := ai.deepen(ctx, g.searchCopy())
+	if !ok {
+		return SearchResult{}, errors.New("no legal moves left")
+	}
+	return result, nil
+}
+
+func (ai *LocalAI) deepen(ctx context.Context, search *TicTacToe) (SearchResult, bool) {
+	if ai.opts.TimeLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ai.opts.TimeLimit)
+		defer cancel()
+	}
+	ai.nodes, ai.stopped, ai.bits = 0, false, nil
+	if bits, err := newBitBoardFrom(&search.State.Board); err == nil && !search.Opts.Ultimate {
+		ai.bits = bits
+	}
+	ai.table.newSearch()
+	result, found := SearchResult{}, false
+	for depth := 1; ai.opts.Depth == 0 || depth <= ai.opts.Depth; depth++ {
+		limit := ctx
+		if depth == 1 {
+			limit = context.Background()
+		} else if ctx.Err() != nil {
+			break
+		}
+		ai.horizon = false
+		score, move, ok := ai.negamax(limit, search, depth, -infinity, infinity)
+		if ai.stopped || !ok {
+			break
+		}
+		result, found = SearchResult{Move: move, Score: score, Depth: depth}, true
+		if !ai.horizon || score >= winScore || score <= -winScore {
+			// Searching deeper can't change the result
+			break
+		}
+	}
+	result.Nodes = ai.nodes
+	result.Table = ai.table.stats
+	ai.last = result
+	return result, found
+}
+
+func (ai *LocalAI) NextTurn(g *GameState) (PlayerTurn, error) {
+	return ai.ChooseMove(g)
+}
+
+// Chooses the colour in a swap opening by searching the position from O's side, who moves next
+// either way
+func (ai *LocalAI) ChooseColour(g *GameState) (PlayerSymbol, error) {
+	if !g.isChoosing() {
+		return EMPTY, errors.New("colours can't be chosen now")
+	}
+	search := g.searchCopy()
+	search.State.openingStones = 0
+	search.State.setTurn(O)
+	if result, _ := ai.deepen(context.Background(), search); result.Score > 0 {
+		return O, nil
+	}
+	return X, nil
+}
+
+// Copy of the game the search can make and take back moves on
+func (g *GameState) searchCopy() *TicTacToe {
+	return &TicTacToe{
+		Opts:  g.opts,
+		State: g.clone(),
+	}
+}
+
+// Returns the score of the position for the player whose turn it is and the best move found for them.
+// The moves are made and taken back on the given game so it has to be a copy of the real one.
+func (ai *LocalAI) negamax(ctx context.Context, t *TicTacToe, depth int, alpha int, beta int) (int, Move, bool) {
+	g := &t.State
+	player := g.Turn
+	hash := g.Hash()
+	entry, hit := ai.table.probe(hash)
+	if hit && entry.depth >= depth {
+		if entry.bound == EXACT || (entry.bound == LOWER_BOUND && entry.score >= beta) || (entry.bound == UPPER_BOUND && entry.score <= alpha) {
+			ai.table.stats.Cutoffs += 1
+			ai.horizon = ai.horizon || entry.horizon
+			return entry.score, entry.move, true
+		}
+	}
+	moves := g.candidateMoves(ai.opts.Radius)
+	if hit {
+		// The best move found before is the likeliest to cause a cutoff again
+		for i, move := range moves {
+			if move == entry.move {
+				moves[0], moves[i] = moves[i], moves[0]
+				break
+			}
+		}
+	}
+	// Whether the position's own search reaches the horizon is stored with it
+	outerHorizon := ai.horizon
+	ai.horizon = false
+	defer func() {
+		ai.horizon = ai.horizon || outerHorizon
+	}()
+	alphaOrig := alpha
+	best, bestMove, found := -infinity, Move{}, false
+	for _, move := range moves {
+		if _, err := t.playMove(move); err != nil {
+			// Ruled out by the rules, eg a forbidden renju move
+			continue
+		}
+		ai.mirrorMove(g.MoveHistory[len(g.MoveHistory)-1], false)
+		ai.nodes++
+		// Checking the context is slow compared to making a move so it's done only now and then
+		if ai.nodes%1024 == 0 && ctx.Err() != nil {
+			ai.stopped = true
+		}
+		var score int
+		if g.Winner == player {
+			// Quicker wins score higher and slower losses lower
+			score = winScore + depth
+		} else if g.Winner != EMPTY {
+			score = -winScore - depth
+		} else if g.Status == TIE {
+			score = 0
+		} else if !g.isTurn() {
+			// Colours are chosen next in a swap opening
+			score = evaluate(g, ai.lines(g), player)
+		} else if depth <= 1 {
+			ai.horizon = true
+			score = evaluate(g, ai.lines(g), player)
+		} else {
+			score, _, _ = ai.negamax(ctx, t, depth-1, -beta, -alpha)
+			score = -score
+		}
+		ai.mirrorMove(g.popMove(), true)
+		if ai.stopped {
+			return 0, Move{}, false
+		}
+		if !found || score > best {
+			best, bestMove, found = score, move, true
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	if !found {
+		return 0, Move{}, false
+	}
+	bound := EXACT
+	if best <= alphaOrig {
+		bound = UPPER_BOUND
+	} else if best >= beta {
+		bound = LOWER_BOUND
+	}
+	ai.table.store(tableEntry{
+		hash:    hash,
+		score:   best,
+		depth:   depth,
+		bound:   bound,
+		move:    bestMove,
+		horizon: ai.horizon,
+	})
+	return best, bestMove, true
+}
+
+// Makes or takes back the move and its captures on the bitboard
+func (ai *LocalAI) mirrorMove(entry HistoryEntry, undo bool) {
+	if ai.bits == nil {
+		return
+	}
+	move := entry.Move
+	if undo {
+		ai.bits.remove(move.X, move.Y)
+	} else {
+		ai.bits.place(move.X, move.Y, move.Player)
+	}
+	for _, cell := range entry.captured {
+		if undo {
+			ai.bits.place(cell.x, cell.y, cell.owner)
+		} else {
+			ai.bits.remove(cell.x, cell.y)
+		}
+	}
+}
+
+// Returns the board the search scores the lines of, the bitboard when there is one
+func (ai *LocalAI) lines(g *GameState) position {
+	if ai.bits != nil {
+		return ai.bits
+	}
+	return &g.Board
+}
+
+// Heuristic score of the position for the player, positive when the player's lines are more promising
+// than the opponent's. The lines of the main board are scored on lines, which has the same stones.
+func evaluate(g *GameState, lines position, player PlayerSymbol) int {
+	opponent := g.nextPlayer(player)
+	score := lines.lineScore(player) - lines.lineScore(opponent)
+	for i := range g.LocalBoards {
+		// Local boards only matter for the cells they win on the meta board
+		score += (g.LocalBoards[i].lineScore(player) - g.LocalBoards[i].lineScore(opponent)) / 8
+	}
+	if g.opts.Misere {
+		// Lines only bring the player closer to losing
+		score = -score
+	}
+	return score + (g.Captures[player]-g.Captures[opponent])*captureScore
+}
+
+// Scores the player's lines by their length and how many of their ends are open. A line blocked from
+// both ends can't grow into a winning line and is worth nothing.
+func (b *Board) lineScore(player PlayerSymbol) int {
+	score := 0
+	for _, cell := range b.cells {
+		if cell.owner != player {
+			continue
+		}
+		for _, dir := range b.directions() {
+			before := b.ownerInDirection(cell, dir, -1)
+			if before == player {
+				// Each line is scored once from its first cell
+				continue
+			}
+			length := int(cell.adjacency[dir])
+			open := 0
+			if before == EMPTY {
+				open += 1
+			}
+			if b.ownerInDirection(cell, dir, length) == EMPTY {
+				open += 1
+			}
+			score += segmentScore(scoredLength(length, b.winLength), open)
+		}
+	}
+	return score
+}
+
+// Longest line the heuristic scores tell apart. Lines of longer win lengths are scored by how many
+// stones they are short of winning so that no line that hasn't won comes near winScore.
+const maxScoredLength = 6
+
+// Returns the length the line is scored by, which up to a win length of maxScoredLength is the line's
+// own length counting no further than the win length
+func scoredLength(length int, winLength int) int {
+	return maxInt(1, minInt(length, winLength)-maxInt(0, winLength-maxScoredLength))
+}
+
+// Each stone in a line is worth 8 times more than the one before and lines open from both ends are
+// worth twice as much as lines open from one
+func segmentScore(length int, open int) int {
+	return open << (3 * length)
+}
+
+// Returns the owner of the cell the given number of steps from the cell in the direction, BLOCKED
+// when the cell is outside the board
+func (b *Board) ownerInDirection(cell BoardCell, dir Adjacency, steps int) PlayerSymbol {
+	step := dir.vector()
+	x, y, z := cell.x+step.X*steps, cell.y+step.Y*steps, cell.z+step.Z*steps
+	if b.torus {
+		x, y, z = (x%b.width+b.width)%b.width, (y%b.height+b.height)%b.height, (z%b.depth+b.depth)%b.depth
+	}
+	if !b.isWithinBoard(x, y, z) {
+		return BLOCKED
+	}
+	return b.getCellAt(x, y, z).owner
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// Lets the AI play both sides until the game ends or the turn limit is reached
+func playAIGame(t *testing.T, game *TicTacToe, ai *LocalAI, limit int) {
+	game.XPlayer.Source, game.OPlayer.Source = ai, ai
+	for i := 0; i < limit && game.isRunning(); i++ {
+		if err := game.PlayNextTurn(); err != nil {
+			t.Fatal("AI's turn failed", err)
+		}
+	}
+}
+
+func TestAIWinsAndBlocks(t *testing.T) {
+	opts := GameOptions{Size: 3, WinLength: 3}
+	game := startGame(t, opts)
+	placeOpeningStones(t, game, []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 0, Y: 1, Player: O},
+		{X: 1, Y: 0, Player: X},
+		{X: 1, Y: 1, Player: O},
+	})
+	ai := NewLocalAI(AIOptions{Depth: 3})
+	if move, err := ai.ChooseMove(&game.State); err != nil || move != (Move{X: 2, Y: 0, Player: X}) {
+		t.Errorf("AI should have won at (2, 0) but chose %v", move)
+	}
+	if ai.nodes == 0 {
+		t.Error("AI didn't count the moves it searched")
+	}
+	game = startGame(t, opts)
+	placeOpeningStones(t, game, []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 1, Y: 1, Player: O},
+		{X: 1, Y: 0, Player: X},
+	})
+	if move, err := ai.ChooseMove(&game.State); err != nil || move != (Move{X: 2, Y: 0, Player: O}) {
+		t.Errorf("AI should have blocked at (2, 0) but chose %v", move)
+	}
+	if game.State.Board.asStateString() != "XX--O----" || len(game.State.MoveHistory) != 3 {
+		t.Error("Searching changed the game")
+	}
+}
+
+func TestAIPerfectPlay(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3})
+	playAIGame(t, game, NewLocalAI(AIOptions{Depth: 9}), 9)
+	if game.State.Status != TIE {
+		t.Errorf("Tic-tac-toe played perfectly should be a tie but status was %s", game.State.Status)
+	}
+}
+
+func TestAIMisere(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3, Misere: true})
+	placeOpeningStones(t, game, []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 0, Y: 1, Player: O},
+		{X: 1, Y: 0, Player: X},
+		{X: 2, Y: 2, Player: O},
+	})
+	move, err := NewLocalAI(AIOptions{Depth: 1}).ChooseMove(&game.State)
+	if err != nil {
+		t.Fatal("AI failed to choose a move", err)
+	} else if move == (Move{X: 2, Y: 0, Player: X}) {
+		t.Error("AI completed a line in a misère game")
+	}
+}
+
+func TestAIVariants(t *testing.T) {
+	if _, err := New(GameOptions{Size: 10, Players: 3, GameType: LOCAL_AI}); err == nil {
+		t.Error("Was able to create a LOCAL_AI game with 3 players")
+	}
+	for _, opts := range []GameOptions{
+		{Size: 7, WinLength: 4, Gravity: true},
+		{Size: 3, WinLength: 3, Ultimate: true},
+		{Size: 9, WinLength: 4, RuleSet: PENTE, CapturesToWin: 2},
+		{Size: 9, WinLength: 4, Opening: SWAP2},
+		{Size: 4, Depth: 4, WinLength: 4},
+		{Unbounded: true, WinLength: 3},
+	} {
+		game := startGame(t, opts)
+		playAIGame(t, game, NewLocalAI(AIOptions{Depth: 2}), 40)
+	}
+}
+
+func TestSearchTimeLimit(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 25})
+	placeOpeningStones(t, game, []Move{
+		{X: 12, Y: 12, Player: X},
+		{X: 13, Y: 13, Player: O},
+		{X: 12, Y: 13, Player: X},
+	})
+	ai := NewLocalAI(AIOptions{TimeLimit: 200 * time.Millisecond})
+	start := time.Now()
+	result, err := ai.Search(context.Background(), &game.State)
+	if err != nil {
+		t.Fatal("Search failed", err)
+	} else if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Search should have stopped after 200ms but took %s", elapsed)
+	}
+	if result.Depth < 1 || result.Nodes == 0 || ai.LastSearch() != result {
+		t.Errorf("Search should have reported its depth and nodes but got %+v", result)
+	}
+	// The first depth is completed even when the search is cancelled straight away
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = NewLocalAI(AIOptions{Depth: 10}).Search(ctx, &game.State)
+	if err != nil || result.Depth != 1 || game.State.Board.getCellAt(result.Move.X, result.Move.Y, 0).owner != EMPTY {
+		t.Errorf("Cancelled search should have returned a move of the first depth but got %+v", result)
+	}
+}
+
+func TestSearchStopsWhenExact(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3})
+	result, err := NewLocalAI(AIOptions{TimeLimit: time.Minute}).Search(context.Background(), &game.State)
+	if err != nil {
+		t.Fatal("Search failed", err)
+	} else if result.Depth > 9 || result.Score != 0 {
+		t.Errorf("Search should have proven a tie within 9 moves but got %+v", result)
+	}
+}
+
+func TestLongWinLengthScores(t *testing.T) {
+	board := newBoard(25, 25, 20)
+	for x := 0; x < 19; x++ {
+		board.updateCell(x, 0, 0, X)
+	}
+	// A line one stone short of winning must not look like a won game to the search
+	if score := board.lineScore(X); score >= winScore || score <= 0 {
+		t.Errorf("Line of 19 should have scored between 0 and winScore but scored %d", score)
+	}
+	bits, _ := newBitBoardFrom(board)
+	if bits.lineScore(X) != board.lineScore(X) {
+		t.Errorf("Bitboard scored the line %d instead of %d", bits.lineScore(X), board.lineScore(X))
+	}
+}
+
+func TestSearchBitBoard(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 9, RuleSet: PENTE})
+	// X can capture O's pair at (3, 4) and (4, 4) by playing (5, 4)
+	placeOpeningStones(t, game, []Move{
+		{X: 2, Y: 4, Player: X},
+		{X: 3, Y: 4, Player: O},
+		{X: 6, Y: 6, Player: X},
+		{X: 4, Y: 4, Player: O},
+	})
+	ai := NewLocalAI(AIOptions{Depth: 3})
+	if _, err := ai.Search(context.Background(), &game.State); err != nil {
+		t.Fatal("Search failed", err)
+	}
+	if ai.bits == nil {
+		t.Fatal("Search should have used a bitboard on a flat bounded board")
+	} else if ai.bits.Hash() != game.State.Board.Hash() {
+		t.Error("Bitboard should have been back at the searched position after the search")
+	}
+	game = startGame(t, GameOptions{Size: 9, Topology: TORUS})
+	if _, err := ai.Search(context.Background(), &game.State); err != nil || ai.bits != nil {
+		t.Errorf("Search on a torus should have used the board itself but failed with %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package game
+
+import (
+	"errors"
+	"math/bits"
+)
+
+type bitset []uint64
+
+func newBitset(bits int) bitset {
+	return make(bitset, (bits+63)/64)
+}
+
+func (s bitset) has(i int) bool {
+	return s[i/64]&(1<<uint(i%64)) != 0
+}
+
+func (s bitset) set(i int) {
+	s[i/64] |= 1 << uint(i%64)
+}
+
+func (s bitset) clear(i int) {
+	s[i/64] &^= 1 << uint(i%64)
+}
+
+func (s bitset) isZero() bool {
+	for _, word := range s {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Keeps the bits of s that are also set n bits further in other, ie s &= other >> n
+func (s bitset) andShifted(other bitset, n int) {
+	words, bits := n/64, uint(n%64)
+	for i := range s {
+		var shifted uint64
+		if i+words < len(other) {
+			shifted = other[i+words] >> bits
+		}
+		if bits != 0 && i+words+1 < len(other) {
+			shifted |= other[i+words+1] << (64 - bits)
+		}
+		s[i] &= shifted
+	}
+}
+
+// Sets s to other shifted n bits down, ie s = other >> n, or up when n is negative
+func (s bitset) shifted(other bitset, n int) {
+	if n < 0 {
+		words, bits := -n/64, uint(-n%64)
+		for i := range s {
+			var shifted uint64
+			if i-words >= 0 {
+				shifted = other[i-words] << bits
+			}
+			if bits != 0 && i-words-1 >= 0 {
+				shifted |= other[i-words-1] >> (64 - bits)
+			}
+			s[i] = shifted
+		}
+		return
+	}
+	for i := range s {
+		s[i] = ^uint64(0)
+	}
+	s.andShifted(other, n)
+}
+
+// Counts the bits set in both s and other
+func (s bitset) countAnd(other bitset) int {
+	count := 0
+	for i := range s {
+		count += bits.OnesCount64(s[i] & other[i])
+	}
+	return count
+}
+
+// Board that keeps a bitset of stones for each player so that lines are found by shifting the
+// bitsets instead of walking cells. Each row has an extra empty cell at its end that stops the
+// shifts from continuing a line from the end of one row to the start of the next.
+type bitBoard struct {
+	width      int
+	height     int
+	winLength  int
+	stride     int
+	emptyCells int
+	hash       uint64
+	// Stones of each player indexed by their symbol, BLOCKED cells are only marked in occupied
+	stones   [BLOCKED + 1]bitset
+	occupied bitset
+	// Bits of the cells on the board, leaving out the extra cells at the ends of the rows
+	cells bitset
+	// Reused by hasLine and lineScore to avoid allocating
+	scratch [5]bitset
+}
+
+func newBitBoard(width int, height int, winLength int) *bitBoard {
+	bits := height * (width + 1)
+	b := &bitBoard{
+		width:      width,
+		height:     height,
+		winLength:  winLength,
+		stride:     width + 1,
+		emptyCells: width * height,
+		occupied:   newBitset(bits),
+		cells:      newBitset(bits),
+	}
+	for i := range b.stones {
+		b.stones[i] = newBitset(bits)
+	}
+	for i := range b.scratch {
+		b.scratch[i] = newBitset(bits)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			b.cells.set(b.index(x, y))
+		}
+	}
+	return b
+}
+
+// Copies the stones of a flat bounded board to a new bitBoard
+func newBitBoardFrom(board *Board) (*bitBoard, error) {
+	if board.unbounded || board.torus || board.depth > 1 {
+		return nil, errors.New("bitboard can only represent flat bounded boards")
+	}
+	b := newBitBoard(board.width, board.height, board.winLength)
+	for _, cell := range board.cells {
+		if cell.owner != EMPTY {
+			b.place(cell.x, cell.y, cell.owner)
+		}
+	}
+	return b, nil
+}
+
+func (b *bitBoard) index(x int, y int) int {
+	return y*b.stride + x
+}
+
+// Distance between the bits of neighbouring cells in the direction
+func (b *bitBoard) shift(dir Adjacency) int {
+	step := dir.vector()
+	return step.Y*b.stride + step.X
+}
+
+func (b *bitBoard) ownerAt(x int, y int) PlayerSymbol {
+	i := b.index(x, y)
+	if !b.occupied.has(i) {
+		return EMPTY
+	}
+	for player := X; player < BLOCKED; player++ {
+		if b.stones[player].has(i) {
+			return player
+		}
+	}
+	return BLOCKED
+}
+
+func (b *bitBoard) place(x int, y int, player PlayerSymbol) {
+	i := b.index(x, y)
+	if !b.occupied.has(i) {
+		b.emptyCells -= 1
+	}
+	b.hash ^= ownerKey(x, y, 0, b.ownerAt(x, y)) ^ ownerKey(x, y, 0, player)
+	for _, stones := range b.stones {
+		stones.clear(i)
+	}
+	b.occupied.set(i)
+	if player != BLOCKED {
+		b.stones[player].set(i)
+	}
+}
+
+func (b *bitBoard) remove(x int, y int) {
+	i := b.index(x, y)
+	if !b.occupied.has(i) || b.ownerAt(x, y) == BLOCKED {
+		return
+	}
+	b.hash ^= ownerKey(x, y, 0, b.ownerAt(x, y))
+	for _, stones := range b.stones {
+		stones.clear(i)
+	}
+	b.occupied.clear(i)
+	b.emptyCells += 1
+}
+
+func (b *bitBoard) lineLength(x int, y int, dir Adjacency) int {
+	player := b.ownerAt(x, y)
+	if player == EMPTY || player == BLOCKED {
+		return 0
+	}
+	stones, step := b.stones[player], dir.vector()
+	count := 1
+	for _, sign := range []int{1, -1} {
+		xx, yy := x+sign*step.X, y+sign*step.Y
+		for xx >= 0 && yy >= 0 && xx < b.width && yy < b.height && stones.has(b.index(xx, yy)) {
+			count += 1
+			xx, yy = xx+sign*step.X, yy+sign*step.Y
+		}
+	}
+	return count
+}
+
+func (b *bitBoard) hasLine(player PlayerSymbol, length int) bool {
+	stones, line := b.stones[player], b.scratch[0]
+	for _, dir := range Adjancies {
+		copy(line, stones)
+		for i := 1; i < length && !line.isZero(); i++ {
+			line.andShifted(stones, i*b.shift(dir))
+		}
+		if !line.isZero() {
+			return true
+		}
+	}
+	return false
+}
+
+// Same as Board.lineScore but finds the lines of each length at once by shifting the bitsets
+func (b *bitBoard) lineScore(player PlayerSymbol) int {
+	stones := b.stones[player]
+	empty, before, line, longer, ends := b.scratch[0], b.scratch[1], b.scratch[2], b.scratch[3], b.scratch[4]
+	for i := range empty {
+		empty[i] = b.cells[i] &^ b.occupied[i]
+	}
+	score := 0
+	for _, dir := range Adjancies {
+		shift := b.shift(dir)
+		// Lines start from the stones with no stone of the player before them
+		before.shifted(stones, -shift)
+		for i := range line {
+			line[i] = stones[i] &^ before[i]
+		}
+		before.shifted(empty, -shift)
+		for length := 1; !line.isZero(); length++ {
+			// Starts of the lines longer than length
+			longer.shifted(stones, length*shift)
+			for i := range longer {
+				longer[i] &= line[i]
+				line[i] &^= longer[i]
+			}
+			ends.shifted(empty, length*shift)
+			open := line.countAnd(before) + line.countAnd(ends)
+			score += segmentScore(scoredLength(length, b.winLength), open)
+			copy(line, longer)
+		}
+	}
+	return score
+}
+
+func (b *bitBoard) isFull() bool {
+	return b.emptyCells == 0
+}
+
+// Same as Board.Hash so that both boards hash the same position equally
+func (b *bitBoard) Hash() uint64 {
+	return b.hash
+}
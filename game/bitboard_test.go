@@ -0,0 +1,142 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBitset(t *testing.T) {
+	s := newBitset(130)
+	s.set(0)
+	s.set(64)
+	s.set(129)
+	if !s.has(64) || s.has(63) || !s.has(129) {
+		t.Errorf("Bitset had wrong bits set: %b", s)
+	}
+	// Keeps only the bits that have a set bit 64 bits above them
+	other := newBitset(130)
+	copy(other, s)
+	s.andShifted(other, 64)
+	if !s.has(0) || s.has(64) || s.has(129) {
+		t.Errorf("Shifted bitset had wrong bits set: %b", s)
+	}
+	// Bits moved up past the word boundary and out of the end of the bitset
+	other.shifted(s, -65)
+	if !other.has(65) || other.countAnd(s) != 0 {
+		t.Errorf("Bitset shifted up had wrong bits set: %b", other)
+	}
+	s.clear(0)
+	if !s.isZero() {
+		t.Errorf("Bitset should have been empty but was %b", s)
+	}
+}
+
+// Checks that both boards agree on everything the position interface tells about them
+func checkSamePosition(t *testing.T, width int, height int, reference position, b position, step int) {
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if reference.ownerAt(x, y) != b.ownerAt(x, y) {
+				t.Fatalf("Step %d: cell at (%d, %d) was owned by %s instead of %s", step, x, y, b.ownerAt(x, y), reference.ownerAt(x, y))
+			}
+			for _, dir := range Adjancies {
+				if expected, got := reference.lineLength(x, y, dir), b.lineLength(x, y, dir); expected != got {
+					t.Fatalf("Step %d: cell at (%d, %d) was in a %s line of %d instead of %d", step, x, y, dir, got, expected)
+				}
+			}
+		}
+	}
+	for _, player := range []PlayerSymbol{X, O} {
+		for length := 2; length <= 6; length++ {
+			if reference.hasLine(player, length) != b.hasLine(player, length) {
+				t.Fatalf("Step %d: boards disagreed whether %s had a line of %d", step, player, length)
+			}
+		}
+		if expected, got := reference.lineScore(player), b.lineScore(player); expected != got {
+			t.Fatalf("Step %d: %s's lines scored %d instead of %d", step, player, got, expected)
+		}
+	}
+	if reference.isFull() != b.isFull() {
+		t.Fatalf("Step %d: boards disagreed whether the board was full", step)
+	}
+	if reference.Hash() != b.Hash() {
+		t.Fatalf("Step %d: boards had different hashes", step)
+	}
+}
+
+func TestBitBoard(t *testing.T) {
+	for _, size := range [][2]int{{3, 3}, {7, 4}, {4, 9}, {15, 15}} {
+		width, height := size[0], size[1]
+		rng := rand.New(rand.NewSource(1))
+		reference, bits := newBoard(width, height, DefaultWinLength), newBitBoard(width, height, DefaultWinLength)
+		for step := 0; step < 1000; step++ {
+			x, y := rng.Intn(width), rng.Intn(height)
+			if reference.ownerAt(x, y) == EMPTY {
+				player := PlayerSymbol(1 + rng.Intn(2))
+				reference.place(x, y, player)
+				bits.place(x, y, player)
+			} else if rng.Intn(4) == 0 {
+				reference.remove(x, y)
+				bits.remove(x, y)
+			}
+			checkSamePosition(t, width, height, reference, bits, step)
+		}
+	}
+}
+
+func TestBitBoardFrom(t *testing.T) {
+	board := newBoard(5, 5, 3)
+	board.applyMask(DiamondMask(5, 5))
+	board.updateCell(2, 2, 0, X)
+	board.updateCell(1, 2, 0, O)
+	bits, err := newBitBoardFrom(board)
+	if err != nil {
+		t.Fatal("Failed to create bitboard", err)
+	}
+	checkSamePosition(t, 5, 5, board, bits, 0)
+	if bits.ownerAt(0, 0) != BLOCKED {
+		t.Error("Blocked cell wasn't blocked on the bitboard")
+	}
+	if _, err := newBitBoardFrom(newTorusBoard(5, 5, 3)); err == nil {
+		t.Error("Was able to create a bitboard from a torus")
+	}
+}
+
+func benchmarkHasLine(b *testing.B, board position) {
+	for y := 0; y < 15; y++ {
+		for x := 0; x < 15; x++ {
+			if (x+y)%3 != 0 {
+				board.place(x, y, PlayerSymbol(1+(x/2+y)%2))
+			}
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		board.place(7, 7, X)
+		board.hasLine(X, DefaultWinLength)
+		board.remove(7, 7)
+	}
+}
+
+func BenchmarkHasLineBoard(b *testing.B) { benchmarkHasLine(b, newBoard(15, 15, DefaultWinLength)) }
+func BenchmarkHasLineBitBoard(b *testing.B) {
+	benchmarkHasLine(b, newBitBoard(15, 15, DefaultWinLength))
+}
+
+func benchmarkLineScore(b *testing.B, board position) {
+	for y := 0; y < 15; y++ {
+		for x := 0; x < 15; x++ {
+			if (x+y)%3 != 0 {
+				board.place(x, y, PlayerSymbol(1+(x/2+y)%2))
+			}
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		board.lineScore(X)
+	}
+}
+
+func BenchmarkLineScoreBoard(b *testing.B) { benchmarkLineScore(b, newBoard(15, 15, DefaultWinLength)) }
+func BenchmarkLineScoreBitBoard(b *testing.B) {
+	benchmarkLineScore(b, newBitBoard(15, 15, DefaultWinLength))
+}
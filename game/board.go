@@ -2,7 +2,7 @@ package game
 
 import (
 	"errors"
-	"fmt"
+	"math"
 	"strings"
 )
 
@@ -13,161 +13,500 @@ const (
 	VERTICAL
 	LEFT_TO_RIGHT_DIAGONAL
 	RIGHT_TO_LEFT_DIAGONAL
+	// Directions that go through the layers of a 3D board. The planar part of the line follows the
+	// direction of the same prefix and z rises or falls towards its top side.
+	DEPTH
+	HORIZONTAL_RISING
+	HORIZONTAL_FALLING
+	VERTICAL_RISING
+	VERTICAL_FALLING
+	LEFT_TO_RIGHT_RISING
+	LEFT_TO_RIGHT_FALLING
+	RIGHT_TO_LEFT_RISING
+	RIGHT_TO_LEFT_FALLING
 )
 
 func (a Adjacency) String() string {
-	return []string{"HORIZONTAL", "VERTICAL", "LEFT_TO_RIGHT_DIAGONAL", "RIGHT_TO_LEFT_DIAGONAL"}[a]
+	return []string{
+		"HORIZONTAL", "VERTICAL", "LEFT_TO_RIGHT_DIAGONAL", "RIGHT_TO_LEFT_DIAGONAL",
+		"DEPTH", "HORIZONTAL_RISING", "HORIZONTAL_FALLING", "VERTICAL_RISING", "VERTICAL_FALLING",
+		"LEFT_TO_RIGHT_RISING", "LEFT_TO_RIGHT_FALLING", "RIGHT_TO_LEFT_RISING", "RIGHT_TO_LEFT_FALLING",
+	}[a]
 }
 
+// Step towards the top side of the direction
+func (a Adjacency) vector() Coord {
+	return []Coord{
+		{1, 0, 0}, {0, 1, 0}, {-1, 1, 0}, {1, 1, 0},
+		{0, 0, 1}, {1, 0, 1}, {1, 0, -1}, {0, 1, 1}, {0, 1, -1},
+		{-1, 1, 1}, {-1, 1, -1}, {1, 1, 1}, {1, 1, -1},
+	}[a]
+}
+
+// Directions of the lines on a flat board
 var Adjancies = [...]Adjacency{HORIZONTAL, VERTICAL, LEFT_TO_RIGHT_DIAGONAL, RIGHT_TO_LEFT_DIAGONAL}
 
+// Directions of the lines on a 3D board
+var SpatialAdjancies = [...]Adjacency{
+	HORIZONTAL, VERTICAL, LEFT_TO_RIGHT_DIAGONAL, RIGHT_TO_LEFT_DIAGONAL,
+	DEPTH, HORIZONTAL_RISING, HORIZONTAL_FALLING, VERTICAL_RISING, VERTICAL_FALLING,
+	LEFT_TO_RIGHT_RISING, LEFT_TO_RIGHT_FALLING, RIGHT_TO_LEFT_RISING, RIGHT_TO_LEFT_FALLING,
+}
+
+type Topology uint8
+
+const (
+	PLANE Topology = iota
+	// Edges of the board wrap around so that lines continue from the right edge to the left and from bottom to top
+	TORUS
+)
+
+func (t Topology) String() string {
+	return []string{"PLANE", "TORUS"}[t]
+}
+
 type PlayerSymbol uint8
 
 const (
 	EMPTY PlayerSymbol = iota
 	X
 	O
+	// Symbols of the third and fourth player in games of more than two players
+	TRIANGLE
+	SQUARE
+	// Unplayable cell that no player can own, breaks every line that goes through it
+	BLOCKED
 )
 
 func (p PlayerSymbol) String() string {
-	return []string{"-", "X", "O"}[p]
+	return []string{"-", "X", "O", "△", "□", "#"}[p]
+}
+
+type Coord struct {
+	X int
+	Y int
+	// Layer of a 3D board, always 0 on flat boards
+	Z int
 }
 
 type BoardCell struct {
-	x         int
-	y         int
-	owner     PlayerSymbol
-	adjacency map[Adjacency]int
+	x     int
+	y     int
+	z     int
+	owner PlayerSymbol
+	// Length of the owner's line through the cell in each direction, kept in an array instead of
+	// a map so that copying the cell copies the lines too. int16 keeps the cells small enough for
+	// the search to copy boards cheaply, see maxLineLength.
+	adjacency [len(SpatialAdjancies)]int16
 }
+
+// Longest line the adjacency of a cell can hold, which bounded boards are kept within by limiting
+// their number of cells
+const maxLineLength = math.MaxInt16
+
 type Board struct {
-	size  int
-	cells []BoardCell
+	width      int
+	height     int
+	depth      int
+	winLength  int
+	emptyCells int
+	cells      []BoardCell
+	torus      bool
+	// Zobrist hash of the owners of the cells
+	hash uint64
+	// Unbounded boards allocate cells on demand and look them up by their coordinates
+	unbounded bool
+	positions map[Coord]int
+	// Corners of the area of an unbounded board that has had stones placed on it
+	min Coord
+	max Coord
 }
 
-func newBoard(size int) *Board {
+func newBoard(width int, height int, winLength int) *Board {
+	return newBoard3D(width, height, 1, winLength)
+}
+
+func newBoard3D(width int, height int, depth int, winLength int) *Board {
 	return &Board{
-		size:  size,
-		cells: *createCells(size),
+		width:      width,
+		height:     height,
+		depth:      depth,
+		winLength:  winLength,
+		emptyCells: width * height * depth,
+		cells:      *createCells(width, height, depth),
 	}
 }
 
+func newUnboundedBoard(winLength int) *Board {
+	return &Board{
+		depth:     1,
+		winLength: winLength,
+		cells:     []BoardCell{},
+		unbounded: true,
+		positions: map[Coord]int{},
+	}
+}
+
+// Copies the board so that nothing is shared with the original. The cells hold no references so
+// bounded boards are copied with a single allocation.
 func (b *Board) clone() Board {
-	cells := make([]BoardCell, b.size*b.size)
+	cells := make([]BoardCell, len(b.cells))
 	copy(cells, b.cells)
+	var positions map[Coord]int
+	if b.unbounded {
+		positions = make(map[Coord]int, len(b.positions))
+		for c, i := range b.positions {
+			positions[c] = i
+		}
+	}
 	return Board{
-		size:  b.size,
-		cells: cells,
+		width:      b.width,
+		height:     b.height,
+		depth:      b.depth,
+		winLength:  b.winLength,
+		emptyCells: b.emptyCells,
+		cells:      cells,
+		torus:      b.torus,
+		hash:       b.hash,
+		unbounded:  b.unbounded,
+		positions:  positions,
+		min:        b.min,
+		max:        b.max,
 	}
 }
 
 func (b *Board) asStateString() string {
-	arr := make([]string, b.size*b.size)
-	for i := 0; i < b.size*b.size; i++ {
-		arr[i] = b.cells[i].owner.String()
+	if b.unbounded && len(b.cells) == 0 {
+		return ""
+	}
+	min, max := b.bounds()
+	arr := make([]string, 0, (max.X-min.X+1)*(max.Y-min.Y+1)*(max.Z-min.Z+1))
+	for z := min.Z; z <= max.Z; z++ {
+		for y := min.Y; y <= max.Y; y++ {
+			for x := min.X; x <= max.X; x++ {
+				arr = append(arr, b.getCellAt(x, y, z).owner.String())
+			}
+		}
 	}
 	return strings.Join(arr, "")
 }
 
-func createCells(size int) *[]BoardCell {
-	cells := make([]BoardCell, size*size)
-	for y := 0; y < size; y++ {
-		for x := 0; x < size; x++ {
-			cells[y*size+x] = BoardCell{
-				x:         x,
-				y:         y,
-				owner:     EMPTY,
-				adjacency: map[Adjacency]int{},
+// Returns the top-left and bottom-right corners of the board or, when unbounded, of its occupied area
+func (b *Board) bounds() (Coord, Coord) {
+	if b.unbounded {
+		return b.min, b.max
+	}
+	return Coord{0, 0, 0}, Coord{b.width - 1, b.height - 1, b.depth - 1}
+}
+
+// Directions in which lines can be made on the board
+func (b *Board) directions() []Adjacency {
+	if b.depth > 1 {
+		return SpatialAdjancies[:]
+	}
+	return Adjancies[:]
+}
+
+func createCells(width int, height int, depth int) *[]BoardCell {
+	cells := make([]BoardCell, width*height*depth)
+	for z := 0; z < depth; z++ {
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				cells[(z*height+y)*width+x] = BoardCell{
+					x:     x,
+					y:     y,
+					z:     z,
+					owner: EMPTY,
+				}
 			}
 		}
 	}
 	return &cells
 }
 
-func (b *Board) isWithinBoard(x int, y int) bool {
-	return x >= 0 && y >= 0 && x < b.size && y < b.size
+// Blocks every cell whose mask value is false, the mask is indexed as mask[y][x]
+func (b *Board) applyMask(mask [][]bool) {
+	for y, row := range mask {
+		for x, playable := range row {
+			if !playable {
+				b.blockCell(x, y, 0)
+			}
+		}
+	}
+}
+
+func (b *Board) blockCell(x int, y int, z int) {
+	i := b.allocCell(x, y, z)
+	if b.cells[i].owner == EMPTY {
+		b.emptyCells -= 1
+	}
+	b.rehash(b.cells[i], BLOCKED)
+	b.cells[i].owner = BLOCKED
 }
 
-func (b *Board) getCellAt(x int, y int) BoardCell {
-	return b.cells[y*b.size+x]
+func (b *Board) unblockCell(x int, y int, z int) {
+	i := b.index(x, y, z)
+	if i == -1 || b.cells[i].owner != BLOCKED {
+		return
+	}
+	b.rehash(b.cells[i], EMPTY)
+	b.cells[i].owner = EMPTY
+	b.emptyCells += 1
 }
 
-func (b *Board) getAdjacentInDirection(x int, y int, dir Adjacency, topSide bool) (BoardCell, error) {
-	xx, yy := x, y
-	switch dir {
-	case HORIZONTAL:
-		if topSide {
-			xx = x + 1
-		} else {
-			xx = x - 1
-		}
-	case VERTICAL:
-		if topSide {
-			yy = y + 1
-		} else {
-			yy = y - 1
-		}
-	case LEFT_TO_RIGHT_DIAGONAL:
-		if topSide {
-			xx, yy = x-1, y+1
-		} else {
-			xx, yy = x+1, y-1
-		}
-	case RIGHT_TO_LEFT_DIAGONAL:
-		if topSide {
-			xx, yy = x+1, y+1
-		} else {
-			xx, yy = x-1, y-1
+func (b *Board) isWithinBoard(x int, y int, z int) bool {
+	inPlane := b.unbounded || (x >= 0 && y >= 0 && x < b.width && y < b.height)
+	return inPlane && z >= 0 && z < b.depth
+}
+
+// Returns the index of the cell at x,y,z or -1 if an unbounded board has not allocated it yet
+func (b *Board) index(x int, y int, z int) int {
+	if b.unbounded {
+		if i, ok := b.positions[Coord{x, y, z}]; ok {
+			return i
 		}
-	default:
-		panic("inside switch-case encountered unknown Adjacency value")
+		return -1
+	}
+	return (z*b.height+y)*b.width + x
+}
+
+func (b *Board) getCellAt(x int, y int, z int) BoardCell {
+	i := b.index(x, y, z)
+	if i == -1 {
+		return BoardCell{x: x, y: y, z: z, owner: EMPTY}
+	}
+	return b.cells[i]
+}
+
+// Same as index but allocates the cell on unbounded boards
+func (b *Board) allocCell(x int, y int, z int) int {
+	i := b.index(x, y, z)
+	if i != -1 {
+		return i
+	}
+	i = len(b.cells)
+	b.cells = append(b.cells, BoardCell{
+		x:     x,
+		y:     y,
+		z:     z,
+		owner: EMPTY,
+	})
+	b.positions[Coord{x, y, z}] = i
+	if i == 0 {
+		b.min, b.max = Coord{x, y, z}, Coord{x, y, z}
+	}
+	b.min = Coord{minInt(b.min.X, x), minInt(b.min.Y, y), minInt(b.min.Z, z)}
+	b.max = Coord{maxInt(b.max.X, x), maxInt(b.max.Y, y), maxInt(b.max.Z, z)}
+	return i
+}
+
+func (b *Board) getAdjacentInDirection(x int, y int, z int, dir Adjacency, topSide bool) (BoardCell, error) {
+	step := dir.vector()
+	if !topSide {
+		step = Coord{-step.X, -step.Y, -step.Z}
+	}
+	xx, yy, zz := x+step.X, y+step.Y, z+step.Z
+	if b.torus {
+		xx, yy, zz = (xx+b.width)%b.width, (yy+b.height)%b.height, (zz+b.depth)%b.depth
 	}
-	if !b.isWithinBoard(xx, yy) {
-		return BoardCell{}, errors.New("x,y values were not inside the board")
+	if !b.isWithinBoard(xx, yy, zz) {
+		return BoardCell{}, errors.New("x,y,z values were not inside the board")
 	}
-	return b.getCellAt(xx, yy), nil
+	return b.getCellAt(xx, yy, zz), nil
+}
+
+// Returns the maximum number of cells in a line, which on a torus is the length of the loop the
+// line makes before wrapping back to its start
+func (b *Board) lineCapacity(dir Adjacency) int {
+	if !b.torus {
+		return len(b.cells)
+	}
+	step := dir.vector()
+	capacity := 1
+	for _, axis := range [][2]int{{step.X, b.width}, {step.Y, b.height}, {step.Z, b.depth}} {
+		if axis[0] != 0 {
+			capacity = capacity / gcd(capacity, axis[1]) * axis[1]
+		}
+	}
+	return capacity
 }
 
 // Gets adjacent cells in a direction until finds a non-player cell
-func (b *Board) getAdjacentCells(x int, y int, player PlayerSymbol, dir Adjacency) []BoardCell {
+func (b *Board) getAdjacentCells(x int, y int, z int, player PlayerSymbol, dir Adjacency) []BoardCell {
+	// Both sides of a line wrapping around a torus end up in the same cells so they share the limit
+	limit := b.lineCapacity(dir) - 1
+	adjacent := b.getAdjacentCellsOnSide(x, y, z, player, dir, true, limit)
+	return append(adjacent, b.getAdjacentCellsOnSide(x, y, z, player, dir, false, limit-len(adjacent))...)
+}
+
+// Same as getAdjacentCells but only walks to one side of x,y,z and returns at most limit cells
+func (b *Board) getAdjacentCellsOnSide(x int, y int, z int, player PlayerSymbol, dir Adjacency, topSide bool, limit int) []BoardCell {
 	var adjacent []BoardCell
-	running, topSide, nowX, nowY, iters := true, true, x, y, 0
-	for running {
-		cell, err := b.getAdjacentInDirection(nowX, nowY, dir, topSide)
-		if iters > 20 {
-			fmt.Println("cell is ", cell)
-			fmt.Println("player", player)
-			fmt.Println("topSide", topSide)
-			panic("infinite loop")
+	nowX, nowY, nowZ := x, y, z
+	for len(adjacent) < limit {
+		cell, err := b.getAdjacentInDirection(nowX, nowY, nowZ, dir, topSide)
+		if err != nil || cell.owner != player {
+			break
 		}
-		if err == nil && cell.owner == player {
-			adjacent = append(adjacent, cell)
-			nowX = cell.x
-			nowY = cell.y
-		} else if topSide {
-			cell = b.getCellAt(x, y)
-			topSide = false
-			nowX = x
-			nowY = y
-		} else {
-			running = false
-		}
-		iters += 1
+		adjacent = append(adjacent, cell)
+		nowX = cell.x
+		nowY = cell.y
+		nowZ = cell.z
 	}
 	return adjacent
 }
 
-func (b *Board) updateCellsInDirection(x int, y int, player PlayerSymbol, dir Adjacency) int {
-	cells := b.getAdjacentCells(x, y, player, dir)
+func (b *Board) updateCellsInDirection(x int, y int, z int, player PlayerSymbol, dir Adjacency) int {
+	cells := b.getAdjacentCells(x, y, z, player, dir)
 	adjacentCount := len(cells) + 1
 	for _, cell := range cells {
-		cell.adjacency[dir] = adjacentCount
+		b.cells[b.index(cell.x, cell.y, cell.z)].adjacency[dir] = int16(adjacentCount)
 	}
 	return adjacentCount
 }
 
-func (b *Board) updateCell(x int, y int, player PlayerSymbol) {
-	b.cells[y*b.size+x].owner = player
-	for _, dir := range Adjancies {
-		b.cells[y*b.size+x].adjacency[dir] = b.updateCellsInDirection(x, y, player, dir)
+func (b *Board) isFull() bool {
+	return !b.unbounded && b.emptyCells == 0
+}
+
+// Checks whether any line of winLength cells could still be filled by a single player
+func (b *Board) hasOpenLine() bool {
+	if b.unbounded {
+		return true
+	}
+	for z := 0; z < b.depth; z++ {
+		for y := 0; y < b.height; y++ {
+			for x := 0; x < b.width; x++ {
+				for _, dir := range b.directions() {
+					if b.isOpenLine(x, y, z, dir) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (b *Board) isOpenLine(x int, y int, z int, dir Adjacency) bool {
+	if b.winLength > b.lineCapacity(dir) {
+		return false
+	}
+	cell := b.getCellAt(x, y, z)
+	owner := cell.owner
+	if owner == BLOCKED {
+		return false
+	}
+	for i := 1; i < b.winLength; i++ {
+		var err error
+		cell, err = b.getAdjacentInDirection(cell.x, cell.y, cell.z, dir, true)
+		if err != nil || cell.owner == BLOCKED {
+			return false
+		} else if owner == EMPTY {
+			owner = cell.owner
+		} else if cell.owner != EMPTY && cell.owner != owner {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *Board) updateCell(x int, y int, z int, player PlayerSymbol) {
+	i := b.allocCell(x, y, z)
+	if b.cells[i].owner == EMPTY {
+		b.emptyCells -= 1
+	}
+	b.rehash(b.cells[i], player)
+	b.cells[i].owner = player
+	for _, dir := range b.directions() {
+		b.cells[i].adjacency[dir] = int16(b.updateCellsInDirection(x, y, z, player, dir))
+	}
+}
+
+// Removes the stone at x,y,z and splits the lines that went through it
+func (b *Board) removeCell(x int, y int, z int) {
+	i := b.index(x, y, z)
+	if i == -1 || b.cells[i].owner == EMPTY || b.cells[i].owner == BLOCKED {
+		return
+	}
+	player := b.cells[i].owner
+	b.rehash(b.cells[i], EMPTY)
+	b.cells[i].owner = EMPTY
+	b.emptyCells += 1
+	for _, dir := range b.directions() {
+		b.cells[i].adjacency[dir] = 0
+		for _, topSide := range []bool{true, false} {
+			cells := b.getAdjacentCellsOnSide(x, y, z, player, dir, topSide, b.lineCapacity(dir)-1)
+			for _, cell := range cells {
+				b.cells[b.index(cell.x, cell.y, cell.z)].adjacency[dir] = int16(len(cells))
+			}
+		}
+	}
+}
+
+// Board representation the search works with on flat bounded boards. Board is the reference
+// implementation that keeps every line's length up to date and bitBoard the faster alternative.
+type position interface {
+	ownerAt(x int, y int) PlayerSymbol
+	place(x int, y int, player PlayerSymbol)
+	remove(x int, y int)
+	// Length of the owner's line through x,y or 0 if the cell is empty
+	lineLength(x int, y int, dir Adjacency) int
+	// Checks whether the player has a line of at least length stones anywhere on the board
+	hasLine(player PlayerSymbol, length int) bool
+	// Heuristic score of the player's lines, see Board.lineScore
+	lineScore(player PlayerSymbol) int
+	isFull() bool
+	Hash() uint64
+}
+
+func (b *Board) ownerAt(x int, y int) PlayerSymbol {
+	return b.getCellAt(x, y, 0).owner
+}
+
+func (b *Board) place(x int, y int, player PlayerSymbol) {
+	b.updateCell(x, y, 0, player)
+}
+
+func (b *Board) remove(x int, y int) {
+	b.removeCell(x, y, 0)
+}
+
+func (b *Board) lineLength(x int, y int, dir Adjacency) int {
+	return int(b.getCellAt(x, y, 0).adjacency[dir])
+}
+
+func (b *Board) hasLine(player PlayerSymbol, length int) bool {
+	for _, cell := range b.cells {
+		if cell.owner != player {
+			continue
+		}
+		for _, count := range cell.adjacency {
+			if int(count) >= length {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func gcd(a int, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
 	}
+	return b
 }
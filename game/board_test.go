@@ -1,17 +1,20 @@
 package game
 
 import (
+	"math/rand"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestNewBoard(t *testing.T) {
 	size := 25
-	board := newBoard(size)
+	board := newBoard(size, size, DefaultWinLength)
 	count := 0
 	var cell BoardCell
 	for y := 0; y < size; y++ {
 		for x := 0; x < size; x++ {
-			cell = board.getCellAt(x, y)
+			cell = board.getCellAt(x, y, 0)
 			if cell.owner != EMPTY {
 				count += 1
 			}
@@ -23,9 +26,9 @@ func TestNewBoard(t *testing.T) {
 }
 
 func CheckCellAdjancies(t *testing.T, b *Board, x int, y int, target map[Adjacency]int) {
-	cell := b.getCellAt(x, y)
+	cell := b.getCellAt(x, y, 0)
 	for _, adj := range Adjancies {
-		count := len(b.getAdjacentCells(x, y, cell.owner, adj))
+		count := len(b.getAdjacentCells(x, y, 0, cell.owner, adj))
 		if count != target[adj] {
 			t.Errorf("Cell at (%d, %d) should have %d adjancies in %s direction but instead had %d", x, y, target[adj], adj.String(), count)
 		}
@@ -34,8 +37,8 @@ func CheckCellAdjancies(t *testing.T, b *Board, x int, y int, target map[Adjacen
 
 func TestAdjancies(t *testing.T) {
 	size := 5
-	board := newBoard(size)
-	cell := board.getCellAt(2, 2)
+	board := newBoard(size, size, DefaultWinLength)
+	cell := board.getCellAt(2, 2, 0)
 	if cell.owner != EMPTY {
 		t.Error("Cell at (2, 2) wasn't empty!")
 	}
@@ -72,7 +75,7 @@ func TestAdjancies(t *testing.T) {
 	for y := 0; y < size; y++ {
 		for x := 0; x < size; x++ {
 			if cells[y][x] != EMPTY {
-				board.updateCell(x, y, cells[y][x])
+				board.updateCell(x, y, 0, cells[y][x])
 			}
 		}
 	}
@@ -114,3 +117,399 @@ func TestAdjancies(t *testing.T) {
 		RIGHT_TO_LEFT_DIAGONAL: 0,
 	})
 }
+
+func TestOpenLines(t *testing.T) {
+	board := newBoard(3, 3, 3)
+	if board.emptyCells != 9 || board.isFull() {
+		t.Errorf("New 3x3 board should have 9 empty cells but had %d", board.emptyCells)
+	}
+	if !board.hasOpenLine() {
+		t.Error("Empty board should have open lines")
+	}
+	// Make following board:
+	//
+	// X|O| |
+	// ------
+	// O|X| |
+	// ------
+	// O|X|O|
+	//
+	cells := [][]PlayerSymbol{{
+		X, O, EMPTY,
+	}, {
+		O, X, EMPTY,
+	}, {
+		O, X, O,
+	}}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			if cells[y][x] != EMPTY {
+				board.updateCell(x, y, 0, cells[y][x])
+			}
+		}
+	}
+	if board.emptyCells != 2 {
+		t.Errorf("Board should have 2 empty cells but had %d", board.emptyCells)
+	}
+	if !board.hasOpenLine() {
+		t.Error("Board should still have an open vertical line on the right")
+	}
+	board.updateCell(2, 0, 0, X)
+	if board.hasOpenLine() {
+		t.Error("Board shouldn't have any open lines left")
+	}
+	board.updateCell(2, 1, 0, O)
+	if !board.isFull() {
+		t.Errorf("Board should be full but had %d empty cells", board.emptyCells)
+	}
+}
+
+func TestRectangularBoard(t *testing.T) {
+	width, height := 7, 4
+	board := newBoard(width, height, DefaultWinLength)
+	if len(board.cells) != width*height || board.emptyCells != width*height {
+		t.Errorf("Created %dx%d board with %d cells and %d empty cells", width, height, len(board.cells), board.emptyCells)
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			cell := board.getCellAt(x, y, 0)
+			if cell.x != x || cell.y != y {
+				t.Errorf("Cell at (%d, %d) had coordinates (%d, %d)", x, y, cell.x, cell.y)
+			}
+		}
+	}
+	if board.isWithinBoard(3, 4, 0) || !board.isWithinBoard(6, 3, 0) || board.isWithinBoard(7, 0, 0) {
+		t.Error("isWithinBoard didn't respect the board width and height")
+	}
+	for y := 0; y < height; y++ {
+		board.updateCell(6, y, 0, X)
+	}
+	CheckCellAdjancies(t, board, 6, 3, map[Adjacency]int{
+		HORIZONTAL:             0,
+		VERTICAL:               3,
+		LEFT_TO_RIGHT_DIAGONAL: 0,
+		RIGHT_TO_LEFT_DIAGONAL: 0,
+	})
+	if board.asStateString() != strings.Repeat("------X", height) {
+		t.Errorf("Board state string was %s", board.asStateString())
+	}
+}
+
+func TestUnboundedBoard(t *testing.T) {
+	board := newUnboundedBoard(DefaultWinLength)
+	if board.asStateString() != "" || len(board.cells) != 0 {
+		t.Error("New unbounded board should have no cells")
+	}
+	if !board.isWithinBoard(-1000, 1000, 0) || board.isFull() || !board.hasOpenLine() {
+		t.Error("Unbounded board should accept any coordinates and never fill up")
+	}
+	if cell := board.getCellAt(-3, 7, 0); cell.owner != EMPTY || cell.x != -3 || cell.y != 7 {
+		t.Errorf("Unallocated cell at (-3, 7) was %v", cell)
+	}
+	for x := -2; x <= 1; x++ {
+		board.updateCell(x, -x, 0, X)
+	}
+	board.updateCell(0, 1, 0, O)
+	if len(board.cells) != 5 {
+		t.Errorf("Unbounded board should have allocated 5 cells but had %d", len(board.cells))
+	}
+	CheckCellAdjancies(t, board, -2, 2, map[Adjacency]int{
+		HORIZONTAL:             0,
+		VERTICAL:               0,
+		LEFT_TO_RIGHT_DIAGONAL: 3,
+		RIGHT_TO_LEFT_DIAGONAL: 0,
+	})
+	min, max := board.bounds()
+	if min != (Coord{-2, -1, 0}) || max != (Coord{1, 2, 0}) {
+		t.Errorf("Occupied area should be from (-2, -1) to (1, 2) but was from %v to %v", min, max)
+	}
+	expected := "" +
+		"---X" +
+		"--X-" +
+		"-XO-" +
+		"X---"
+	if board.asStateString() != expected {
+		t.Errorf("Board state string was %s", board.asStateString())
+	}
+	clone := board.clone()
+	clone.updateCell(5, 5, 0, O)
+	if len(board.cells) != 5 || board.getCellAt(5, 5, 0).owner != EMPTY {
+		t.Error("Placing a stone on a cloned unbounded board changed the original")
+	}
+}
+
+func TestMergedLines(t *testing.T) {
+	size := 25
+	board := newBoard(size, size, DefaultWinLength)
+	// Two segments of X on the same row that get joined by (8, 3)
+	for x := 0; x < size; x++ {
+		if x != 8 {
+			board.updateCell(x, 3, 0, X)
+		}
+	}
+	if count := board.getCellAt(0, 3, 0).adjacency[HORIZONTAL]; count != 8 {
+		t.Errorf("Left segment should have length 8 but had %d", count)
+	}
+	if count := board.getCellAt(24, 3, 0).adjacency[HORIZONTAL]; count != 16 {
+		t.Errorf("Right segment should have length 16 but had %d", count)
+	}
+	board.updateCell(8, 3, 0, X)
+	for x := 0; x < size; x++ {
+		if count := board.getCellAt(x, 3, 0).adjacency[HORIZONTAL]; int(count) != size {
+			t.Errorf("Cell at (%d, 3) should be in a line of %d but had %d", x, size, count)
+		}
+	}
+}
+
+func newTorusBoard(width int, height int, winLength int) *Board {
+	board := newBoard(width, height, winLength)
+	board.torus = true
+	return board
+}
+
+func TestTorusBoard(t *testing.T) {
+	board := newTorusBoard(5, 5, 3)
+	if cell, err := board.getAdjacentInDirection(4, 0, 0, RIGHT_TO_LEFT_DIAGONAL, true); err != nil || cell.x != 0 || cell.y != 1 {
+		t.Errorf("Cell after (4, 0) should have wrapped to (0, 1) but was %v", cell)
+	}
+	if cell, err := board.getAdjacentInDirection(0, 0, 0, VERTICAL, false); err != nil || cell.x != 0 || cell.y != 4 {
+		t.Errorf("Cell above (0, 0) should have wrapped to (0, 4) but was %v", cell)
+	}
+	board.updateCell(4, 2, 0, X)
+	board.updateCell(0, 2, 0, X)
+	board.updateCell(1, 2, 0, X)
+	CheckCellAdjancies(t, board, 0, 2, map[Adjacency]int{
+		HORIZONTAL:             2,
+		VERTICAL:               0,
+		LEFT_TO_RIGHT_DIAGONAL: 0,
+		RIGHT_TO_LEFT_DIAGONAL: 0,
+	})
+	// A line going all the way around is counted only once
+	for x := 0; x < 5; x++ {
+		board.updateCell(x, 2, 0, X)
+	}
+	for x := 0; x < 5; x++ {
+		if count := board.getCellAt(x, 2, 0).adjacency[HORIZONTAL]; count != 5 {
+			t.Errorf("Cell at (%d, 2) should be in a line of 5 but was in %d", x, count)
+		}
+	}
+	board.removeCell(2, 2, 0)
+	for x := 0; x < 5; x++ {
+		if count := board.getCellAt(x, 2, 0).adjacency[HORIZONTAL]; x != 2 && count != 4 {
+			t.Errorf("Cell at (%d, 2) should be in a line of 4 after removal but was in %d", x, count)
+		}
+	}
+	// On a 3x4 torus the diagonals visit every cell before wrapping back to the start
+	board = newTorusBoard(3, 4, 3)
+	if capacity := board.lineCapacity(LEFT_TO_RIGHT_DIAGONAL); capacity != 12 {
+		t.Errorf("Diagonal of a 3x4 torus should have room for 12 cells but had %d", capacity)
+	}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 3; x++ {
+			board.updateCell(x, y, 0, O)
+		}
+	}
+	cell := board.getCellAt(1, 1, 0)
+	if cell.adjacency[HORIZONTAL] != 3 || cell.adjacency[VERTICAL] != 4 || cell.adjacency[LEFT_TO_RIGHT_DIAGONAL] != 12 || cell.adjacency[RIGHT_TO_LEFT_DIAGONAL] != 12 {
+		t.Errorf("Cell on a full torus had adjacency %v", cell.adjacency)
+	}
+	// Lines can't wrap onto themselves to fit the win length
+	board = newTorusBoard(3, 4, 4)
+	if board.isOpenLine(0, 0, 0, HORIZONTAL) || !board.isOpenLine(0, 0, 0, VERTICAL) {
+		t.Error("Only vertical lines of 4 should fit on a 3x4 torus")
+	}
+}
+
+func TestBoard3D(t *testing.T) {
+	board := newBoard3D(4, 4, 4, 4)
+	if len(board.directions()) != 13 || board.emptyCells != 64 {
+		t.Errorf("4x4x4 board should have 13 directions and 64 cells but had %d and %d", len(board.directions()), board.emptyCells)
+	}
+	if cell, err := board.getAdjacentInDirection(1, 1, 1, LEFT_TO_RIGHT_FALLING, true); err != nil || cell.x != 0 || cell.y != 2 || cell.z != 0 {
+		t.Errorf("Cell after (1, 1, 1) should have been (0, 2, 0) but was %v", cell)
+	}
+	// Space diagonal from the top-left corner of the first layer to the bottom-right of the last
+	for i := 0; i < 3; i++ {
+		board.updateCell(i, i, i, X)
+	}
+	board.updateCell(1, 1, 0, X)
+	board.updateCell(1, 1, 2, X)
+	cell := board.getCellAt(1, 1, 1)
+	if cell.adjacency[RIGHT_TO_LEFT_RISING] != 3 || cell.adjacency[DEPTH] != 3 || cell.adjacency[HORIZONTAL] != 1 {
+		t.Errorf("Cell at (1, 1, 1) had adjacency %v", cell.adjacency)
+	}
+	board.updateCell(3, 3, 3, X)
+	if count := board.getCellAt(0, 0, 0).adjacency[RIGHT_TO_LEFT_RISING]; count != 4 {
+		t.Errorf("Cell at (0, 0, 0) should be in a line of 4 but was in %d", count)
+	}
+	board.removeCell(1, 1, 1)
+	if count := board.getCellAt(1, 1, 2).adjacency[DEPTH]; count != 1 {
+		t.Errorf("Cell at (1, 1, 2) should be in a line of 1 after removal but was in %d", count)
+	}
+	// X and O share the first row of the first layer, but lines through the layers stay open
+	board = newBoard3D(3, 3, 3, 3)
+	for z := 0; z < 3; z++ {
+		board.updateCell(0, z, z, X)
+		board.updateCell(1, z, z, O)
+	}
+	if board.isOpenLine(0, 0, 0, HORIZONTAL) || !board.isOpenLine(2, 0, 0, DEPTH) || !board.isOpenLine(0, 0, 0, VERTICAL_RISING) {
+		t.Error("Row shared by X and O should be closed and the lines through the layers open")
+	}
+}
+
+// Counts the cells in the line of the cell's owner through the cell by stepping over the board one cell
+// at a time, independently of the adjacency bookkeeping
+func countLine(b *Board, cell BoardCell, dir Adjacency) int {
+	step := dir.vector()
+	count := 1
+	for _, sign := range []int{1, -1} {
+		x, y, z := cell.x, cell.y, cell.z
+		for {
+			x, y, z = x+sign*step.X, y+sign*step.Y, z+sign*step.Z
+			if b.torus {
+				x, y, z = (x+b.width)%b.width, (y+b.height)%b.height, (z+b.depth)%b.depth
+			}
+			if x == cell.x && y == cell.y && z == cell.z {
+				// The whole loop around the torus belongs to the owner
+				return count
+			} else if !b.isWithinBoard(x, y, z) || b.getCellAt(x, y, z).owner != cell.owner {
+				break
+			}
+			count += 1
+		}
+	}
+	return count
+}
+
+// Compares the adjacency of every cell to the lines counted from scratch, step identifies the board
+// in the failure message
+func checkRecomputedAdjacency(t *testing.T, b *Board, step int) {
+	empty := 0
+	for _, cell := range b.cells {
+		if cell.owner == EMPTY {
+			empty += 1
+			if cell.adjacency != [len(SpatialAdjancies)]int16{} {
+				t.Fatalf("Step %d: empty cell at (%d, %d, %d) had adjacency %v", step, cell.x, cell.y, cell.z, cell.adjacency)
+			}
+			continue
+		}
+		for _, dir := range b.directions() {
+			if count := countLine(b, cell, dir); int(cell.adjacency[dir]) != count {
+				t.Fatalf("Step %d: cell at (%d, %d, %d) should be in a %s line of %d but was in %d\n%s",
+					step, cell.x, cell.y, cell.z, dir, count, cell.adjacency[dir], b.asStateString())
+			}
+		}
+	}
+	if !b.unbounded && empty != b.emptyCells {
+		t.Fatalf("Step %d: board had %d empty cells but counted %d", step, empty, b.emptyCells)
+	}
+}
+
+func TestRemoveCellRecomputation(t *testing.T) {
+	// Every position of a 3x3 board with every stone of it removed in turn
+	for _, torus := range []bool{false, true} {
+		positions := 1
+		for i := 0; i < 9; i++ {
+			positions *= 3
+		}
+		for position := 0; position < positions; position++ {
+			owners := make([]PlayerSymbol, 9)
+			for i, p := 0, position; i < 9; i, p = i+1, p/3 {
+				owners[i] = PlayerSymbol(p % 3)
+			}
+			for removed, owner := range owners {
+				if owner == EMPTY {
+					continue
+				}
+				board := newBoard(3, 3, 3)
+				board.torus = torus
+				for i, owner := range owners {
+					if owner != EMPTY {
+						board.updateCell(i%3, i/3, 0, owner)
+					}
+				}
+				board.removeCell(removed%3, removed/3, 0)
+				checkRecomputedAdjacency(t, board, position)
+			}
+		}
+	}
+
+	// Random sequences of added and removed stones on larger boards
+	tests := []struct {
+		name  string
+		board *Board
+	}{
+		{"square", newBoard(5, 5, 3)},
+		{"rectangle", newBoard(7, 4, 4)},
+		{"torus", newTorusBoard(5, 5, 3)},
+		{"rectangular torus", newTorusBoard(3, 4, 3)},
+		{"cube", newBoard3D(3, 3, 3, 3)},
+		{"unbounded", newUnboundedBoard(4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			board := tt.board
+			width, height, depth := board.width, board.height, board.depth
+			if board.unbounded {
+				width, height = 6, 6
+			}
+			for step := 0; step < 2000; step++ {
+				x, y, z := rng.Intn(width), rng.Intn(height), rng.Intn(depth)
+				// Stones are added more often than removed so that long lines build up
+				if board.getCellAt(x, y, z).owner == EMPTY {
+					board.updateCell(x, y, z, PlayerSymbol(1+rng.Intn(2)))
+				} else if rng.Intn(3) == 0 {
+					board.removeCell(x, y, z)
+				}
+				checkRecomputedAdjacency(t, board, step)
+			}
+		})
+	}
+}
+
+// Fills the board with a pattern of lines for both players
+func fillBoard(b *Board) {
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			if (x+y)%3 != 0 {
+				b.updateCell(x, y, 0, PlayerSymbol(1+(x/2+y)%2))
+			}
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	for _, size := range []int{15, 25} {
+		board := newBoard(size, size, DefaultWinLength)
+		fillBoard(board)
+		snapshot := board.clone()
+		clone := board.clone()
+		for y := 0; y < size; y++ {
+			clone.removeCell(0, y, 0)
+			clone.updateCell(1, y, 0, X)
+		}
+		if !reflect.DeepEqual(board.cells, snapshot.cells) || board.emptyCells != snapshot.emptyCells {
+			t.Errorf("Changing the clone of a %dx%d board changed the original", size, size)
+		}
+		if reflect.DeepEqual(clone.cells, snapshot.cells) {
+			t.Errorf("Clone of a %dx%d board didn't change", size, size)
+		}
+		if allocs := testing.AllocsPerRun(10, func() { board.clone() }); allocs != 1 {
+			t.Errorf("Cloning a %dx%d board took %.0f allocations", size, size, allocs)
+		}
+	}
+}
+
+func benchmarkClone(b *testing.B, size int) {
+	board := newBoard(size, size, DefaultWinLength)
+	fillBoard(board)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		board.clone()
+	}
+}
+
+func BenchmarkClone15(b *testing.B) { benchmarkClone(b, 15) }
+func BenchmarkClone25(b *testing.B) { benchmarkClone(b, 25) }
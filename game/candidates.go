@@ -0,0 +1,175 @@
+package game
+
+import (
+	"sort"
+)
+
+// DefaultRadius is used when AIOptions.Radius is left unset
+const DefaultRadius = 2
+
+const (
+	// Threat scores of making a winning line and of blocking one, larger than the scores of any number
+	// of shorter lines
+	winThreat   = 1 << 40
+	blockThreat = 1 << 34
+)
+
+// Returns the empty cells within radius of a stone, the most threatening for the player first. An
+// empty board gives its centre and a board whose stones have no empty cells near them all its empty
+// cells.
+func (b *Board) candidateCells(player PlayerSymbol, opponent PlayerSymbol, radius int) []BoardCell {
+	if b.unbounded && len(b.cells) == 0 {
+		return []BoardCell{b.getCellAt(0, 0, 0)}
+	}
+	seen := map[Coord]bool{}
+	var cells []BoardCell
+	stones := 0
+	layers := minInt(radius, b.depth-1)
+	for _, stone := range b.cells {
+		if stone.owner == EMPTY || stone.owner == BLOCKED {
+			continue
+		}
+		stones += 1
+		for dz := -layers; dz <= layers; dz++ {
+			for dy := -radius; dy <= radius; dy++ {
+				for dx := -radius; dx <= radius; dx++ {
+					x, y, z := stone.x+dx, stone.y+dy, stone.z+dz
+					if b.torus {
+						x, y, z = (x%b.width+b.width)%b.width, (y%b.height+b.height)%b.height, (z%b.depth+b.depth)%b.depth
+					}
+					c := Coord{x, y, z}
+					if seen[c] || !b.isWithinBoard(x, y, z) {
+						continue
+					}
+					seen[c] = true
+					if cell := b.getCellAt(x, y, z); cell.owner == EMPTY {
+						cells = append(cells, cell)
+					}
+				}
+			}
+		}
+	}
+	if stones == 0 && !b.unbounded {
+		if centre := b.getCellAt(b.width/2, b.height/2, b.depth/2); centre.owner == EMPTY {
+			return []BoardCell{centre}
+		}
+	}
+	if len(cells) == 0 {
+		for _, cell := range b.cells {
+			if cell.owner == EMPTY {
+				cells = append(cells, cell)
+			}
+		}
+	}
+	b.orderByThreat(cells, player, opponent)
+	return cells
+}
+
+// Sorts the empty cells by their threat score for the player, keeping the board order of equal cells
+func (b *Board) orderByThreat(cells []BoardCell, player PlayerSymbol, opponent PlayerSymbol) {
+	scores := make(map[Coord]int, len(cells))
+	for _, cell := range cells {
+		scores[Coord{cell.x, cell.y, cell.z}] = b.threatScore(cell, player, opponent)
+	}
+	sort.SliceStable(cells, func(i, j int) bool {
+		return scores[Coord{cells[i].x, cells[i].y, cells[i].z}] > scores[Coord{cells[j].x, cells[j].y, cells[j].z}]
+	})
+}
+
+// Quick estimate of how urgent it is for the player to place a stone on the empty cell. Making a
+// winning line comes first, blocking the opponent's winning line next and then making and blocking
+// shorter lines.
+func (b *Board) threatScore(cell BoardCell, player PlayerSymbol, opponent PlayerSymbol) int {
+	score := 0
+	for _, dir := range b.directions() {
+		if own := b.lineIfPlaced(cell, dir, player); own >= b.winLength {
+			score += winThreat
+		} else {
+			score += 2 << (2 * scoredLength(own, b.winLength))
+		}
+		if other := b.lineIfPlaced(cell, dir, opponent); other >= b.winLength {
+			score += blockThreat
+		} else {
+			score += 1 << (2 * scoredLength(other, b.winLength))
+		}
+	}
+	return score
+}
+
+// Returns the length of the player's line through the cell in the direction if the player placed a
+// stone on it, counting no further than the win length to each side
+func (b *Board) lineIfPlaced(cell BoardCell, dir Adjacency, player PlayerSymbol) int {
+	length := 1
+	for _, sign := range []int{1, -1} {
+		for steps := 1; steps < b.winLength && b.ownerInDirection(cell, dir, sign*steps) == player; steps++ {
+			length += 1
+		}
+	}
+	return length
+}
+
+// Returns the moves worth trying for the player whose turn it is, the most promising first. They can
+// still be rejected by the rules, eg by the renju restrictions or a pro opening, which playMove then
+// checks.
+func (g *GameState) candidateMoves(radius int) []Move {
+	player, opponent := g.Turn, g.nextPlayer(g.Turn)
+	var moves []Move
+	if g.opts.Ultimate {
+		// Every cell of a local board sends the opponent somewhere else so none can be left out
+		w, h := g.Board.width, g.Board.height
+		for i := range g.LocalBoards {
+			c := Coord{i % w, i / w, 0}
+			if g.Board.getCellAt(c.X, c.Y, 0).owner != EMPTY || (g.Target != nil && *g.Target != c) {
+				continue
+			}
+			for _, cell := range g.LocalBoards[i].cells {
+				if cell.owner == EMPTY {
+					moves = append(moves, Move{X: c.X*w + cell.x, Y: c.Y*h + cell.y, Player: player})
+				}
+			}
+		}
+		return moves
+	}
+	var cells []BoardCell
+	if g.opts.Gravity {
+		for x := 0; x < g.Board.width; x++ {
+			if y, ok := g.Board.dropRow(x); ok {
+				cells = append(cells, g.Board.getCellAt(x, y, 0))
+			}
+		}
+		g.Board.orderByThreat(cells, player, opponent)
+	} else {
+		if g.placed == 2 {
+			// X's second stone of a pro opening has to be placed further away
+			radius = maxInt(radius, g.opts.Opening.proDistance())
+		}
+		cells = g.Board.candidateCells(player, opponent, radius)
+	}
+	for _, cell := range cells {
+		moves = append(moves, Move{X: cell.x, Y: cell.y, Z: cell.z, Player: player})
+	}
+	if g.opts.Misere {
+		// Lines lose misère games so the most threatening cells are the worst
+		for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+			moves[i], moves[j] = moves[j], moves[i]
+		}
+	}
+	return moves
+}
+
+// Returns up to n cells worth considering for the player whose turn it is, the most urgent first
+func (g *GameState) Hints(n int) []Coord {
+	if !g.isTurn() {
+		return nil
+	}
+	var hints []Coord
+	for _, move := range g.candidateMoves(DefaultRadius) {
+		if len(hints) == n {
+			break
+		} else if g.checkForbidden(move) != nil || g.checkOpening(move) != nil {
+			continue
+		}
+		hints = append(hints, Coord{move.X, move.Y, move.Z})
+	}
+	return hints
+}
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestCandidateCells(t *testing.T) {
+	board := newBoard(15, 15, 5)
+	for x := 7; x <= 10; x++ {
+		board.updateCell(x, 7, 0, X)
+	}
+	for x := 7; x <= 9; x++ {
+		board.updateCell(x, 8, 0, O)
+	}
+	cells := board.candidateCells(X, O, 2)
+	if first := cells[0]; first.y != 7 || (first.x != 6 && first.x != 11) {
+		t.Errorf("X's winning cell should have come first but got (%d, %d)", first.x, first.y)
+	}
+	// O can't win so blocking X's four comes first
+	if first := board.candidateCells(O, X, 2)[0]; first.y != 7 || (first.x != 6 && first.x != 11) {
+		t.Errorf("Blocking X's four should have come first but got (%d, %d)", first.x, first.y)
+	}
+	// Cells from x 5 to 12 and y 5 to 10 minus the stones and the corner at (12, 10), which is 3 cells
+	// from the closest stones
+	if len(cells) != 8*6-7-1 {
+		t.Errorf("Expected %d cells within 2 of the stones but got %d", 8*6-7-1, len(cells))
+	}
+	for _, cell := range cells {
+		if cell.owner != EMPTY || cell.x < 5 || cell.x > 12 || cell.y < 5 || cell.y > 10 {
+			t.Errorf("Cell (%d, %d) owned by %s shouldn't have been a candidate", cell.x, cell.y, cell.owner)
+		}
+	}
+
+	board = newBoard(5, 5, 3)
+	if cells := board.candidateCells(X, O, 2); len(cells) != 1 || cells[0].x != 2 || cells[0].y != 2 {
+		t.Errorf("Empty board should have given only its centre but got %d cells", len(cells))
+	}
+	board.blockCell(2, 2, 0)
+	if cells := board.candidateCells(X, O, 2); len(cells) != 24 {
+		t.Errorf("Empty board with its centre blocked should have given every empty cell but got %d", len(cells))
+	}
+
+	// Blocking a win still comes before a long line of the player's own with a long win length
+	board = newBoard(25, 25, 20)
+	for x := 0; x < 19; x++ {
+		board.updateCell(x, 2, 0, O)
+	}
+	for x := 0; x < 18; x++ {
+		board.updateCell(x, 5, 0, X)
+	}
+	if first := board.candidateCells(X, O, 1)[0]; first.x != 19 || first.y != 2 {
+		t.Errorf("Blocking O's line of 19 should have come first but got (%d, %d)", first.x, first.y)
+	}
+
+	torus := newTorusBoard(5, 5, 3)
+	torus.updateCell(0, 0, 0, X)
+	if cells := torus.candidateCells(X, O, 1); len(cells) != 8 {
+		t.Errorf("Stone in the corner of a torus should have had 8 neighbours but got %d", len(cells))
+	}
+}
+
+func TestHints(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 9, WinLength: 4})
+	placeOpeningStones(t, game, []Move{
+		{X: 4, Y: 4, Player: X},
+		{X: 0, Y: 0, Player: O},
+		{X: 5, Y: 4, Player: X},
+		{X: 0, Y: 8, Player: O},
+		{X: 6, Y: 4, Player: X},
+	})
+	// O has to block either end of X's three
+	hints := game.State.Hints(2)
+	if len(hints) != 2 {
+		t.Fatalf("Expected 2 hints but got %v", hints)
+	}
+	for _, c := range hints {
+		if c.Y != 4 || (c.X != 3 && c.X != 7) {
+			t.Errorf("Hints should have blocked X's three but got %v", hints)
+		}
+	}
+	game.EndGame()
+	if hints := game.State.Hints(2); hints != nil {
+		t.Errorf("Ended game shouldn't have had hints but got %v", hints)
+	}
+}
@@ -0,0 +1,76 @@
+package game
+
+// Number of enemy stones that get captured when flanked
+const captureLength = 2
+
+// Used for GameOptions.CapturesToWin when it's left unset with the PENTE rule-set
+const DefaultCapturesToWin = 5
+
+// Returns the enemy stones the player's stone at x,y,z flanks. A pair is flanked when exactly two stones
+// of the same enemy are followed by the player's own stone. Each stone is returned once even when a
+// torus lets it be flanked from both sides.
+func (b *Board) findCaptures(x int, y int, z int, player PlayerSymbol) []Coord {
+	var captured []Coord
+	seen := map[Coord]bool{}
+	for _, dir := range b.directions() {
+		if b.torus && b.lineCapacity(dir) <= captureLength+1 {
+			// The loop is too short for the flanking stone to be any other than the one just placed
+			continue
+		}
+		for _, topSide := range []bool{true, false} {
+			line := make([]BoardCell, 0, captureLength+1)
+			nowX, nowY, nowZ := x, y, z
+			for len(line) < captureLength+1 {
+				cell, err := b.getAdjacentInDirection(nowX, nowY, nowZ, dir, topSide)
+				if err != nil {
+					break
+				}
+				line = append(line, cell)
+				nowX, nowY, nowZ = cell.x, cell.y, cell.z
+			}
+			if isFlanked(line, player) {
+				for _, cell := range line[:captureLength] {
+					if c := (Coord{cell.x, cell.y, cell.z}); !seen[c] {
+						seen[c] = true
+						captured = append(captured, c)
+					}
+				}
+			}
+		}
+	}
+	return captured
+}
+
+func isFlanked(line []BoardCell, player PlayerSymbol) bool {
+	if len(line) != captureLength+1 || line[captureLength].owner != player {
+		return false
+	}
+	enemy := line[0].owner
+	if enemy == BLOCKED {
+		return false
+	}
+	for _, cell := range line[:captureLength] {
+		if cell.owner == EMPTY || cell.owner == player || cell.owner != enemy {
+			return false
+		}
+	}
+	return true
+}
+
+// Removes the stones captured by the move and returns the cells they were in
+func (g *GameState) capture(move Move) []BoardCell {
+	if g.opts.RuleSet != PENTE {
+		return nil
+	}
+	var captured []BoardCell
+	for _, c := range g.Board.findCaptures(move.X, move.Y, move.Z, move.Player) {
+		captured = append(captured, g.Board.getCellAt(c.X, c.Y, c.Z))
+		g.Board.removeCell(c.X, c.Y, c.Z)
+	}
+	g.Captures[move.Player] += len(captured) / captureLength
+	return captured
+}
+
+func (g *GameState) checkCaptureWin(move Move) bool {
+	return g.opts.RuleSet == PENTE && g.Captures[move.Player] >= g.opts.CapturesToWin
+}
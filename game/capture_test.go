@@ -0,0 +1,166 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestRemoveCell(t *testing.T) {
+	board := newBoard(7, 7, DefaultWinLength)
+	for x := 0; x < 5; x++ {
+		board.updateCell(x, 3, 0, X)
+	}
+	board.updateCell(2, 2, 0, X)
+	board.updateCell(2, 4, 0, O)
+	board.removeCell(2, 3, 0)
+	if cell := board.getCellAt(2, 3, 0); cell.owner != EMPTY || board.emptyCells != 7*7-6 {
+		t.Errorf("Removed cell was %v with %d empty cells on board", cell, board.emptyCells)
+	}
+	CheckCellAdjancies(t, board, 0, 3, map[Adjacency]int{
+		HORIZONTAL:             1,
+		VERTICAL:               0,
+		LEFT_TO_RIGHT_DIAGONAL: 0,
+		RIGHT_TO_LEFT_DIAGONAL: 0,
+	})
+	for x, count := range []int{2, 2, 0, 2, 2} {
+		if x != 2 && int(board.getCellAt(x, 3, 0).adjacency[HORIZONTAL]) != count {
+			t.Errorf("Cell at (%d, 3) should be in a horizontal line of %d but was in %d", x, count, board.getCellAt(x, 3, 0).adjacency[HORIZONTAL])
+		}
+	}
+	if count := board.getCellAt(2, 2, 0).adjacency[VERTICAL]; count != 1 {
+		t.Errorf("Cell at (2, 2) should be alone vertically but was in a line of %d", count)
+	}
+	board.removeCell(2, 3, 0)
+	if board.emptyCells != 7*7-6 {
+		t.Error("Removing an empty cell changed the number of empty cells")
+	}
+}
+
+func TestFindCaptures(t *testing.T) {
+	// Make following board:
+	//
+	// X|O|O| |O|X|
+	// ------------
+	//  |O| | |O| |
+	// ------------
+	//  |X| |O|X| |
+	//
+	cells := [][]PlayerSymbol{{
+		X, O, O, EMPTY, O, X,
+	}, {
+		EMPTY, O, EMPTY, EMPTY, O, EMPTY,
+	}, {
+		EMPTY, X, EMPTY, O, X, EMPTY,
+	}}
+	board := newBoard(6, 3, DefaultWinLength)
+	for y := range cells {
+		for x := range cells[y] {
+			if cells[y][x] != EMPTY {
+				board.updateCell(x, y, 0, cells[y][x])
+			}
+		}
+	}
+	board.updateCell(3, 0, 0, X)
+	captured := board.findCaptures(3, 0, 0, X)
+	if len(captured) != 2 || captured[0] != (Coord{2, 0, 0}) || captured[1] != (Coord{1, 0, 0}) {
+		t.Errorf("X at (3, 0) should have captured (2, 0) and (1, 0) but captured %v", captured)
+	}
+	// Three stones or a pair of different owners can't be captured
+	if captured := board.findCaptures(1, 2, 0, X); len(captured) != 0 {
+		t.Errorf("X at (1, 2) shouldn't have captured anything but captured %v", captured)
+	}
+}
+
+func TestTorusCaptures(t *testing.T) {
+	// Rows of 3 cells loop back to the stone just placed from both sides
+	game := startGame(t, GameOptions{Width: 3, Height: 5, WinLength: 3, Topology: TORUS, RuleSet: PENTE})
+	moves := []Move{
+		{X: 0, Y: 2, Player: X},
+		{X: 1, Y: 0, Player: O},
+		{X: 2, Y: 3, Player: X},
+		{X: 2, Y: 0, Player: O},
+	}
+	for _, move := range moves {
+		if _, err := game.HandlePlayerTurn(move); err != nil {
+			t.Fatalf("Move %v was rejected: %s", move, err)
+		}
+	}
+	result, err := game.HandlePlayerTurn(Move{X: 0, Y: 0, Player: X})
+	if err != nil {
+		t.Fatal("Move was rejected", err)
+	} else if len(result.Captured) != 0 || game.State.Captures[X] != 0 {
+		t.Errorf("X shouldn't have flanked O's pair with its own stone but captured %v", result.Captured)
+	}
+
+	// A row of 4 cells is long enough for a real capture, which is counted once
+	board := newTorusBoard(4, 4, 3)
+	board.updateCell(1, 0, 0, O)
+	board.updateCell(2, 0, 0, O)
+	board.updateCell(3, 0, 0, X)
+	board.updateCell(0, 0, 0, X)
+	if captured := board.findCaptures(0, 0, 0, X); len(captured) != 2 {
+		t.Errorf("X should have captured one pair but got %v", captured)
+	}
+}
+
+func TestPenteGame(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 10, RuleSet: PENTE, CapturesToWin: 2})
+	moves := []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 1, Y: 0, Player: O},
+		{X: 9, Y: 9, Player: X},
+		{X: 2, Y: 0, Player: O},
+	}
+	for _, move := range moves {
+		if _, err := game.HandlePlayerTurn(move); err != nil {
+			t.Fatalf("Move %v was rejected: %s", move, err)
+		}
+	}
+	result, err := game.HandlePlayerTurn(Move{X: 3, Y: 0, Player: X})
+	if err != nil {
+		t.Fatal("Capturing move was rejected", err)
+	}
+	if len(result.Captured) != 2 || game.State.Captures[X] != 1 || result.Status != O_TURN {
+		t.Errorf("X should have captured one pair but result was %v with %d captures", result, game.State.Captures[X])
+	}
+	if game.State.Board.getCellAt(1, 0, 0).owner != EMPTY || game.State.Board.getCellAt(3, 0, 0).adjacency[HORIZONTAL] != 1 {
+		t.Error("Captured stones weren't removed from the board")
+	}
+	// O may play back into the captured cells without being captured
+	moves = []Move{
+		{X: 1, Y: 0, Player: O},
+		{X: 9, Y: 8, Player: X},
+		{X: 2, Y: 0, Player: O},
+		{X: 5, Y: 5, Player: X},
+		{X: 5, Y: 6, Player: O},
+	}
+	for _, move := range moves {
+		if result, err := game.HandlePlayerTurn(move); err != nil || len(result.Captured) != 0 {
+			t.Fatalf("Move %v should have been played without captures but got %v, %v", move, result, err)
+		}
+	}
+	game.HandlePlayerTurn(Move{X: 8, Y: 9, Player: X})
+	game.HandlePlayerTurn(Move{X: 5, Y: 7, Player: O})
+	result, _ = game.HandlePlayerTurn(Move{X: 5, Y: 8, Player: X})
+	if result.Status != X_WON || game.State.WinReason != CAPTURES {
+		t.Errorf("X should have won by captures but status was %s with reason %s", result.Status, game.State.WinReason)
+	}
+}
+
+func TestWinReason(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 10})
+	for x := 0; x < DefaultWinLength; x++ {
+		game.HandlePlayerTurn(Move{X: x, Y: 0, Player: X})
+		game.HandlePlayerTurn(Move{X: x, Y: 1, Player: O})
+	}
+	if game.State.Status != X_WON || game.State.WinReason != LINE {
+		t.Errorf("X should have won with a line but status was %s with reason %s", game.State.Status, game.State.WinReason)
+	}
+	game = startGame(t, GameOptions{Size: 10, RuleSet: X_OVERLINE_LOSES})
+	for _, x := range []int{0, 1, 2, 4, 5, 3} {
+		game.HandlePlayerTurn(Move{X: x, Y: 0, Player: X})
+		game.HandlePlayerTurn(Move{X: x, Y: 2 + x%2, Player: O})
+	}
+	if game.State.Status != O_WON || game.State.WinReason != OPPONENT_OVERLINE {
+		t.Errorf("O should have won by X's overline but status was %s with reason %s", game.State.Status, game.State.WinReason)
+	}
+}
@@ -7,7 +7,10 @@ import (
 
 type PlayerType int
 
-const MaxPlayers = 2
+const MaxPlayers = 4
+
+// DefaultWinLength is used when GameOptions.WinLength is left unset
+const DefaultWinLength = 5
 
 const (
 	HUMAN PlayerType = iota
@@ -24,89 +27,330 @@ type Player struct {
 	Type            PlayerType
 	Symbol          PlayerSymbol
 	AcceptedRematch bool
+	// Where the player's turns come from when the game is played with PlayNextTurn
+	Source MoveSource
 }
 
 type GameOptions struct {
-	Size     int
-	GameType GameType
+	Width  int
+	Height int
+	// Number of layers of a 3D board, eg 4 for a 4x4x4 Qubic cube. Left unset for a flat board.
+	Depth int
+	// Shorthand for a square board, used for Width and Height when they are left unset
+	Size      int
+	WinLength int
+	RuleSet   RuleSet
+	GameType  GameType
+	Opening   Opening
+	// Number of captured pairs needed to win with the PENTE rule-set
+	CapturesToWin int
+	// Ends the game in a tie as soon as neither player can complete a line anymore
+	EarlyDraw bool
+	Topology  Topology
+	// Board without edges that grows in every direction, Width and Height are left unset
+	Unbounded bool
+	// Stones drop to the lowest empty cell of their column like in Connect Four
+	Gravity bool
+	// Number of players taking turns, 2 when left unset
+	Players int
+	// Marks shown for the players' symbols instead of the defaults, eg {TRIANGLE: "Y"}
+	Marks map[PlayerSymbol]string
+	// Playable cells of the board indexed as Mask[y][x], false marks a blocked cell. Leave unset
+	// for a fully playable board or see DiamondMask and CrossMask for ready-made shapes.
+	Mask [][]bool
+	// Plays on a Width x Height grid of local boards of the same size. Moves use coordinates spanning all
+	// the local boards and winning a local board claims its cell on the meta board.
+	Ultimate bool
+	// Making a line of WinLength loses the game instead of winning it
+	Misere bool
+	// Takes away Undo and Redo, eg in rated games
+	DisableUndo bool
+	// Settings of the computer opponent in LOCAL_AI games
+	AI AIOptions
+}
+
+func (o *GameOptions) setDefaults() {
+	if o.Width == 0 {
+		o.Width = o.Size
+	}
+	if o.Height == 0 {
+		o.Height = o.Size
+	}
+	if o.Depth == 0 && !o.Unbounded {
+		o.Depth = 1
+	}
+	if o.WinLength == 0 {
+		o.WinLength = DefaultWinLength
+	}
+	if o.Players == 0 {
+		o.Players = 2
+	}
+	if o.RuleSet == PENTE && o.CapturesToWin == 0 {
+		o.CapturesToWin = DefaultCapturesToWin
+	}
+	if o.AI.Depth == 0 && o.AI.TimeLimit == 0 {
+		o.AI.Depth = DefaultAIDepth
+	}
+}
+
+func (o *GameOptions) validate() error {
+	if o.WinLength < 0 {
+		return fmt.Errorf("win length must be positive, got %d", o.WinLength)
+	} else if o.CapturesToWin < 0 {
+		return fmt.Errorf("captures to win must be positive, got %d", o.CapturesToWin)
+	} else if o.Unbounded && (o.Width != 0 || o.Height != 0 || o.Depth != 0) {
+		return fmt.Errorf("unbounded board can't have dimensions, got %dx%dx%d", o.Width, o.Height, o.Depth)
+	} else if !o.Unbounded && (o.Width <= 0 || o.Height <= 0 || o.Depth <= 0) {
+		return fmt.Errorf("board dimensions must be positive, got %dx%dx%d", o.Width, o.Height, o.Depth)
+	} else if o.Size != 0 && (o.Size != o.Width || o.Size != o.Height) {
+		return fmt.Errorf("size %d conflicts with board dimensions %dx%d", o.Size, o.Width, o.Height)
+	} else if !o.Unbounded && o.WinLength > o.Width && o.WinLength > o.Height && o.WinLength > o.Depth {
+		return fmt.Errorf("win length %d doesn't fit on a %dx%dx%d board", o.WinLength, o.Width, o.Height, o.Depth)
+	} else if o.Players < 2 || o.Players > MaxPlayers {
+		return fmt.Errorf("game must have 2 to %d players, got %d", MaxPlayers, o.Players)
+	} else if o.Players > 2 && (o.RuleSet == X_OVERLINE_LOSES || o.RuleSet == RENJU) {
+		return fmt.Errorf("%s rule-set can only be played by two players", o.RuleSet)
+	} else if o.Players > 2 && o.Opening != FREE_OPENING {
+		return fmt.Errorf("%s opening can only be played by two players", o.Opening)
+	} else if o.AI.Depth < 0 {
+		return fmt.Errorf("AI depth must be positive, got %d", o.AI.Depth)
+	} else if o.AI.TimeLimit < 0 {
+		return fmt.Errorf("AI time limit must be positive, got %s", o.AI.TimeLimit)
+	} else if o.AI.TableSize < 0 {
+		return fmt.Errorf("AI table size must be positive, got %d", o.AI.TableSize)
+	} else if o.AI.Radius < 0 {
+		return fmt.Errorf("AI radius must be positive, got %d", o.AI.Radius)
+	} else if o.GameType == LOCAL_AI && o.Players > 2 {
+		return errors.New("AI can only play two player games")
+	} else if o.Misere && o.Players > 2 {
+		return errors.New("misère game can only be played by two players")
+	} else if o.Misere && (o.RuleSet == X_OVERLINE_LOSES || o.RuleSet == RENJU) {
+		return fmt.Errorf("%s rule-set can't be used in a misère game", o.RuleSet)
+	} else if o.Misere && o.Ultimate {
+		return errors.New("ultimate game can't be played as misère")
+	} else if o.Topology == TORUS && o.Unbounded {
+		return errors.New("unbounded board can't wrap around as a torus")
+	} else if o.Topology == TORUS && o.RuleSet == RENJU {
+		return errors.New("renju rules can't be played on a torus")
+	} else if o.Depth > 1 && o.RuleSet == RENJU {
+		return errors.New("renju rules can't be played on a 3D board")
+	} else if o.Depth > 1 && o.Gravity {
+		return errors.New("gravity can't be used with a 3D board")
+	} else if o.Depth > 1 && o.Mask != nil {
+		return errors.New("mask can't be used with a 3D board")
+	} else if o.Ultimate && (o.Unbounded || o.Depth > 1 || o.Gravity || o.Mask != nil || o.Topology == TORUS) {
+		return errors.New("ultimate game can only be played on flat rectangular boards")
+	} else if o.Ultimate && (o.RuleSet == RENJU || o.RuleSet == PENTE) {
+		return fmt.Errorf("%s rule-set can't be used in an ultimate game", o.RuleSet)
+	} else if o.Ultimate && o.Opening != FREE_OPENING {
+		return fmt.Errorf("%s opening can't be played in an ultimate game", o.Opening)
+	} else if o.Gravity && o.Unbounded {
+		return errors.New("gravity can't be used with an unbounded board")
+	} else if o.Gravity && o.RuleSet == PENTE {
+		// Captures would leave the stones above them floating
+		return fmt.Errorf("%s rule-set can't be played with gravity", o.RuleSet)
+	} else if o.Gravity && o.Opening.proDistance() > 0 {
+		return fmt.Errorf("%s opening can't be played with gravity", o.Opening)
+	} else if o.Mask != nil && o.Unbounded {
+		return errors.New("unbounded board can't have a mask")
+	} else if err := o.validateMask(); err != nil {
+		return err
+	} else if !o.Unbounded && o.Width*o.Height*o.Depth > maxLineLength {
+		return fmt.Errorf("board can have at most %d cells, got %dx%dx%d", maxLineLength, o.Width, o.Height, o.Depth)
+	} else if d := o.Opening.proDistance(); !o.Unbounded && o.Width/2 < d && o.Height/2 < d && o.Depth/2 < d {
+		return fmt.Errorf("%s opening needs cells %d away from the centre but board was %dx%d", o.Opening, d, o.Width, o.Height)
+	}
+	return nil
+}
+
+func (o *GameOptions) validateMask() error {
+	if o.Mask == nil {
+		return nil
+	} else if len(o.Mask) != o.Height {
+		return fmt.Errorf("mask has %d rows but board height was %d", len(o.Mask), o.Height)
+	}
+	for y, row := range o.Mask {
+		if len(row) != o.Width {
+			return fmt.Errorf("mask row %d has %d cells but board width was %d", y, len(row), o.Width)
+		}
+	}
+	if o.Opening.proDistance() > 0 && !o.Mask[o.Height/2][o.Width/2] {
+		return fmt.Errorf("%s opening starts from the centre but it was blocked", o.Opening)
+	}
+	if o.Gravity {
+		// Stones can't drop past a blocked cell so the playable cells below one would never be filled
+		for x := 0; x < o.Width; x++ {
+			for y := 1; y+1 < o.Height; y++ {
+				if !o.Mask[y][x] && o.Mask[y+1][x] && o.hasPlayableAbove(x, y) {
+					return fmt.Errorf("gravity can't drop stones past the blocked cell at (%d, %d)", x, y)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Checks whether the mask has a playable cell above x,y in its column
+func (o *GameOptions) hasPlayableAbove(x int, y int) bool {
+	for above := 0; above < y; above++ {
+		if o.Mask[above][x] {
+			return true
+		}
+	}
+	return false
 }
 
 type TicTacToe struct {
-	ID      string
-	Opts    GameOptions
-	State   GameState
+	ID    string
+	Opts  GameOptions
+	State GameState
+	// Players in the order they take turns, the first two are also available as XPlayer and OPlayer
+	Players []*Player
 	XPlayer *Player
 	OPlayer *Player
 }
 
-func New(opts GameOptions) *TicTacToe {
+func New(opts GameOptions) (*TicTacToe, error) {
+	opts.setDefaults()
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
 	return &TicTacToe{
 		ID:    "unique-id",
 		Opts:  opts,
-		State: GameState{},
-	}
+		State: GameState{opts: opts},
+	}, nil
 }
 
 func (t *TicTacToe) isFull() bool {
-	return t.XPlayer != nil && t.OPlayer != nil
+	return len(t.Players) == t.Opts.Players
 }
 
 func (t *TicTacToe) isRunning() bool {
-	return t.State.Status == X_TURN || t.State.Status == O_TURN
+	return t.State.isRunning()
+}
+
+// Returns the player who places the next stone, which during a swap opening is the player placing
+// the stones of both colours
+func (t *TicTacToe) turnPlayer() *Player {
+	if t.State.Placer != EMPTY {
+		return t.Players[t.State.Placer-1]
+	}
+	return t.Players[t.State.Turn-1]
 }
 
 func (t *TicTacToe) AddPlayer(playerType PlayerType, user User) (*Player, error) {
 	if t.isFull() {
 		return nil, errors.New("game already full")
 	}
-	var player *Player
-	if t.XPlayer == nil {
-		player = &Player{
-			User:            user,
-			Type:            playerType,
-			Symbol:          X,
-			AcceptedRematch: false,
-		}
+	player := &Player{
+		User:            user,
+		Type:            playerType,
+		Symbol:          PlayerSymbol(len(t.Players) + 1),
+		AcceptedRematch: false,
+	}
+	t.Players = append(t.Players, player)
+	if player.Symbol == X {
 		t.XPlayer = player
-	} else {
-		player = &Player{
-			User:            user,
-			Type:            playerType,
-			Symbol:          O,
-			AcceptedRematch: false,
-		}
+	} else if player.Symbol == O {
 		t.OPlayer = player
 	}
 	return player, nil
 }
 
-func (t *TicTacToe) HandlePlayerTurn(move Move) error {
-	if t.State.Status != X_TURN && t.State.Status != O_TURN {
-		return errors.New("game has already ended")
-	} else if t.State.Status == X_TURN && move.Player != X {
-		return fmt.Errorf("%s tried to move on X's turn", move.Player.String())
-	} else if t.State.Status == O_TURN && move.Player != O {
-		return fmt.Errorf("%s tried to move on O's turn", move.Player.String())
-	} else if !t.State.Board.isWithinBoard(move.X, move.Y) {
-		return errors.New("x, y wasn't inside the board")
+func (t *TicTacToe) HandlePlayerTurn(turn PlayerTurn) (*MoveResult, error) {
+	if !t.isRunning() {
+		return nil, errors.New("game has already ended")
+	} else if t.State.isChoosing() {
+		return nil, errors.New("colours haven't been chosen yet")
+	}
+	move, err := turn.toMove(&t.State)
+	if err != nil {
+		return nil, err
+	}
+	result, err := t.playMove(move)
+	if err != nil {
+		return nil, err
+	}
+	// A new move replaces the moves that could have been redone
+	t.State.undone = nil
+	return result, nil
+}
+
+// Plays the move and adds it to the move history
+func (t *TicTacToe) playMove(move Move) (*MoveResult, error) {
+	if !t.State.isTurn() {
+		return nil, errors.New("it isn't anyone's turn")
+	} else if move.Player != t.State.Turn {
+		return nil, fmt.Errorf("%s tried to move on %s's turn", move.Player.String(), t.State.Turn.String())
+	}
+	previous := t.State.turnState()
+	var result *MoveResult
+	var captured []BoardCell
+	var err error
+	if t.Opts.Ultimate {
+		result, err = t.handleUltimateTurn(move)
+	} else {
+		result, captured, err = t.handleMove(move)
 	}
-	current := t.State.Board.getCellAt(move.X, move.Y)
-	if current.owner != EMPTY {
-		return errors.New("cell already selected")
+	if err != nil {
+		return nil, err
 	}
-	t.State.Board.updateCell(move.X, move.Y, move.Player)
-	return t.State.updateGameStatus(move)
+	t.State.MoveHistory = append(t.State.MoveHistory, HistoryEntry{
+		MoveResult: *result,
+		previous:   previous,
+		captured:   captured,
+	})
+	return result, nil
+}
+
+// Places the stone of the move and returns the stones it captured as they were before the capture
+func (t *TicTacToe) handleMove(move Move) (*MoveResult, []BoardCell, error) {
+	if !t.State.Board.isWithinBoard(move.X, move.Y, move.Z) {
+		return nil, nil, errors.New("x, y wasn't inside the board")
+	}
+	current := t.State.Board.getCellAt(move.X, move.Y, move.Z)
+	if current.owner == BLOCKED {
+		return nil, nil, errors.New("cell is blocked")
+	} else if current.owner != EMPTY {
+		return nil, nil, errors.New("cell already selected")
+	}
+	if err := t.State.checkForbidden(move); err != nil {
+		return nil, nil, err
+	} else if err := t.State.checkOpening(move); err != nil {
+		return nil, nil, err
+	}
+	t.State.Board.updateCell(move.X, move.Y, move.Z, move.Player)
+	captured := t.State.capture(move)
+	t.State.placed += 1
+	if err := t.State.updateGameStatus(move); err != nil {
+		return nil, nil, err
+	}
+	t.State.updateOpening()
+	var coords []Coord
+	for _, cell := range captured {
+		coords = append(coords, Coord{cell.x, cell.y, cell.z})
+	}
+	return &MoveResult{
+		Move:     move,
+		Captured: coords,
+		Status:   t.State.Status,
+	}, captured, nil
 }
 
 func (t *TicTacToe) StartGame() error {
 	if !t.isFull() {
 		return errors.New("game is not full")
 	}
-	t.State = *newState(t.Opts.Size)
-	t.State.Status = X_TURN
+	t.State = *newState(t.Opts)
+	t.State.setTurn(X)
 	return nil
 }
 
+func (t *TicTacToe) mark(player PlayerSymbol) string {
+	return t.State.mark(player)
+}
+
 func (t *TicTacToe) EndGame() {
 	t.State.Status = TIE
 }
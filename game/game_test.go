@@ -1,15 +1,19 @@
 package game
 
 import (
+	"fmt"
 	"testing"
 )
 
 func TestAddingPlayers(t *testing.T) {
 	size := 25
-	game := New(GameOptions{
+	game, err := New(GameOptions{
 		Size:     size,
 		GameType: HOT_SEAT,
 	})
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	}
 	var startErr, addErr error
 	startErr = game.StartGame()
 	if startErr == nil {
@@ -41,10 +45,13 @@ func TestAddingPlayers(t *testing.T) {
 
 func TestNewGame(t *testing.T) {
 	size := 25
-	game := New(GameOptions{
+	game, err := New(GameOptions{
 		Size:     size,
 		GameType: HOT_SEAT,
 	})
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	}
 	game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
 	game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
 	game.StartGame()
@@ -84,3 +91,349 @@ func TestNewGame(t *testing.T) {
 		t.Error("Game did not end to X victory", game)
 	}
 }
+
+func TestWinLengthValidation(t *testing.T) {
+	if _, err := New(GameOptions{Size: 3, WinLength: 4}); err == nil {
+		t.Error("Was able to create a 3x3 game with win length 4")
+	}
+	if _, err := New(GameOptions{Size: 3}); err == nil {
+		t.Error("Was able to create a 3x3 game with the default win length")
+	}
+	if _, err := New(GameOptions{Size: 3, WinLength: -1}); err == nil {
+		t.Error("Was able to create a game with negative win length")
+	}
+	game, err := New(GameOptions{Size: 10})
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	} else if game.Opts.WinLength != DefaultWinLength {
+		t.Errorf("Unset win length should default to %d but was %d", DefaultWinLength, game.Opts.WinLength)
+	}
+}
+
+func TestWinLength(t *testing.T) {
+	tests := []struct {
+		size      int
+		winLength int
+		moves     []Move
+		status    GameStatus
+	}{
+		{3, 3, []Move{
+			{X: 0, Y: 0, Player: X},
+			{X: 0, Y: 1, Player: O},
+			{X: 1, Y: 1, Player: X},
+			{X: 0, Y: 2, Player: O},
+			{X: 2, Y: 2, Player: X},
+		}, X_WON},
+		{6, 4, []Move{
+			{X: 0, Y: 5, Player: X},
+			{X: 0, Y: 0, Player: O},
+			{X: 1, Y: 5, Player: X},
+			{X: 1, Y: 0, Player: O},
+			{X: 2, Y: 5, Player: X},
+			{X: 2, Y: 0, Player: O},
+			{X: 5, Y: 5, Player: X},
+			{X: 3, Y: 0, Player: O},
+		}, O_WON},
+		{8, 6, []Move{
+			{X: 0, Y: 0, Player: X},
+			{X: 7, Y: 0, Player: O},
+			{X: 0, Y: 1, Player: X},
+			{X: 7, Y: 1, Player: O},
+			{X: 0, Y: 2, Player: X},
+			{X: 7, Y: 2, Player: O},
+			{X: 0, Y: 3, Player: X},
+			{X: 7, Y: 3, Player: O},
+			{X: 0, Y: 4, Player: X},
+			{X: 6, Y: 6, Player: O},
+		}, X_TURN},
+	}
+	for _, test := range tests {
+		game, err := New(GameOptions{Size: test.size, WinLength: test.winLength})
+		if err != nil {
+			t.Fatal("Failed to create game", err)
+		}
+		game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+		game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+		game.StartGame()
+		for _, move := range test.moves {
+			if _, err := game.HandlePlayerTurn(move); err != nil {
+				t.Errorf("Move %v was rejected: %s", move, err)
+			}
+		}
+		if game.State.Status != test.status {
+			t.Errorf("Game of %d in a row on %dx%d board should have status %s but had %s", test.winLength, test.size, test.size, test.status, game.State.Status)
+		}
+	}
+}
+
+func TestTie(t *testing.T) {
+	// Make following board:
+	//
+	// X|O|X|
+	// ------
+	// X|O|O|
+	// ------
+	// O|X|X|
+	//
+	moves := []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 1, Y: 0, Player: O},
+		{X: 2, Y: 0, Player: X},
+		{X: 1, Y: 1, Player: O},
+		{X: 0, Y: 1, Player: X},
+		{X: 2, Y: 1, Player: O},
+		{X: 1, Y: 2, Player: X},
+		{X: 0, Y: 2, Player: O},
+		{X: 2, Y: 2, Player: X},
+	}
+	for _, earlyDraw := range []bool{false, true} {
+		game, err := New(GameOptions{Size: 3, WinLength: 3, EarlyDraw: earlyDraw})
+		if err != nil {
+			t.Fatal("Failed to create game", err)
+		}
+		game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+		game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+		game.StartGame()
+		for i, move := range moves {
+			if _, err := game.HandlePlayerTurn(move); err != nil && (!earlyDraw || i < 8) {
+				t.Errorf("Move %v at index %d was rejected: %s", move, i, err)
+			}
+			if earlyDraw && i == 7 && game.State.Status != TIE {
+				t.Errorf("Game with early draw should have ended in a tie after move %d but had status %s", i, game.State.Status)
+			} else if !earlyDraw && i == 7 && game.State.Status != X_TURN {
+				t.Errorf("Game without early draw should have continued after move %d but had status %s", i, game.State.Status)
+			}
+		}
+		if game.State.Status != TIE {
+			t.Errorf("Full board should have ended in a tie but had status %s", game.State.Status)
+		}
+	}
+}
+
+func TestBoardDimensions(t *testing.T) {
+	if _, err := New(GameOptions{Width: 0, Height: 5}); err == nil {
+		t.Error("Was able to create a game with zero width")
+	}
+	if _, err := New(GameOptions{Width: 5, Height: -3}); err == nil {
+		t.Error("Was able to create a game with negative height")
+	}
+	if _, err := New(GameOptions{Size: 5, Width: 7}); err == nil {
+		t.Error("Was able to create a game with conflicting size and width")
+	}
+	if _, err := New(GameOptions{Width: 4, Height: 3, WinLength: 5}); err == nil {
+		t.Error("Was able to create a 4x3 game with win length 5")
+	}
+	if _, err := New(GameOptions{Width: 200, Height: 200}); err == nil {
+		t.Error("Was able to create a board with more cells than a line can hold")
+	}
+	if _, err := New(GameOptions{Width: 5, Height: 3, WinLength: 5}); err != nil {
+		t.Error("Wasn't able to create a 5x3 game with win length 5", err)
+	}
+	game, err := New(GameOptions{Width: 15, Height: 19})
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	}
+	game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+	game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+	game.StartGame()
+	if _, err := game.HandlePlayerTurn(Move{X: 15, Y: 0, Player: X}); err == nil {
+		t.Error("Was able to move outside the 15x19 board")
+	}
+	for y := 14; y < 19; y++ {
+		game.HandlePlayerTurn(Move{X: 14, Y: y, Player: X})
+		game.HandlePlayerTurn(Move{X: 0, Y: y, Player: O})
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won on the bottom right edge but game had status %s", game.State.Status)
+	}
+}
+
+func TestUnboundedGame(t *testing.T) {
+	if _, err := New(GameOptions{Size: 5, Unbounded: true}); err == nil {
+		t.Error("Was able to create an unbounded game with a size")
+	}
+	game, err := New(GameOptions{Unbounded: true})
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	}
+	game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+	game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+	game.StartGame()
+	for i := 0; i < DefaultWinLength; i++ {
+		if _, err := game.HandlePlayerTurn(Move{X: -2 + i, Y: -100, Player: X}); err != nil {
+			t.Errorf("Move was rejected: %s", err)
+		}
+		if i < DefaultWinLength-1 {
+			game.HandlePlayerTurn(Move{X: 1000, Y: i, Player: O})
+		}
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won on the unbounded board but game had status %s", game.State.Status)
+	}
+}
+
+func TestRuleSets(t *testing.T) {
+	// X joins two segments into an overline with (2, 0) while O has four in a row
+	//
+	// X|X| |X|X|X|
+	// ------------
+	// O|O|O|O| | |
+	//
+	moves := []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 0, Y: 1, Player: O},
+		{X: 1, Y: 0, Player: X},
+		{X: 1, Y: 1, Player: O},
+		{X: 3, Y: 0, Player: X},
+		{X: 2, Y: 1, Player: O},
+		{X: 4, Y: 0, Player: X},
+		{X: 3, Y: 1, Player: O},
+		{X: 5, Y: 0, Player: X},
+		{X: 5, Y: 5, Player: O},
+		{X: 2, Y: 0, Player: X},
+	}
+	tests := []struct {
+		rules  RuleSet
+		status GameStatus
+	}{
+		{FREESTYLE, X_WON},
+		{STANDARD, O_TURN},
+		{X_OVERLINE_LOSES, O_WON},
+	}
+	for _, test := range tests {
+		game, err := New(GameOptions{Size: 10, RuleSet: test.rules})
+		if err != nil {
+			t.Fatal("Failed to create game", err)
+		}
+		game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+		game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+		game.StartGame()
+		for _, move := range moves {
+			if _, err := game.HandlePlayerTurn(move); err != nil {
+				t.Errorf("Move %v was rejected with %s rules: %s", move, test.rules, err)
+			}
+		}
+		if game.State.Status != test.status {
+			t.Errorf("Overline with %s rules should have status %s but had %s", test.rules, test.status, game.State.Status)
+		}
+	}
+	// O is allowed to win with an overline when only X's overlines lose
+	game, _ := New(GameOptions{Size: 12, RuleSet: X_OVERLINE_LOSES})
+	game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+	game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+	game.StartGame()
+	for i, x := range []int{0, 1, 3, 4, 5, 2} {
+		game.HandlePlayerTurn(Move{X: 11, Y: i * 2, Player: X})
+		game.HandlePlayerTurn(Move{X: x, Y: 0, Player: O})
+	}
+	if game.State.Status != O_WON {
+		t.Errorf("O should have won with an overline but game had status %s", game.State.Status)
+	}
+}
+
+func startGame(t *testing.T, opts GameOptions) *TicTacToe {
+	game, err := New(opts)
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	}
+	game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+	game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+	if err := game.StartGame(); err != nil {
+		t.Fatal("Failed to start game", err)
+	}
+	return game
+}
+
+func TestMultiplayerGame(t *testing.T) {
+	if _, err := New(GameOptions{Size: 10, Players: 5}); err == nil {
+		t.Error("Was able to create a game with 5 players")
+	}
+	if _, err := New(GameOptions{Size: 10, Players: 3, RuleSet: RENJU}); err == nil {
+		t.Error("Was able to create a renju game with 3 players")
+	}
+	game, err := New(GameOptions{Size: 10, WinLength: 4, Players: 3})
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := game.StartGame(); err == nil {
+			t.Errorf("Was able to start a 3 player game with %d players", i)
+		}
+		game.AddPlayer(HUMAN, User{ID: fmt.Sprint(i), name: fmt.Sprintf("Player %d", i)})
+	}
+	if _, err := game.AddPlayer(HUMAN, User{ID: "4", name: "Player 4"}); err == nil {
+		t.Error("Was able to add a fourth player to a 3 player game")
+	}
+	if game.XPlayer != game.Players[0] || game.OPlayer != game.Players[1] || game.Players[2].Symbol != TRIANGLE {
+		t.Error("Players didn't get the symbols in the order they were added")
+	}
+	game.StartGame()
+	turns := []GameStatus{X_TURN, O_TURN, PLAYER_TURN}
+	for i := 0; i < 4; i++ {
+		for j, player := range []PlayerSymbol{X, O, TRIANGLE} {
+			if game.State.Status != turns[j] || game.State.Turn != player {
+				t.Fatalf("Should have been %s's turn but status was %s with turn %s", player, game.State.Status, game.State.Turn)
+			}
+			other := game.State.nextPlayer(player)
+			if _, err := game.HandlePlayerTurn(Move{X: 9, Y: 9, Player: other}); err == nil {
+				t.Errorf("%s was able to move on %s's turn", other, player)
+			}
+			// Only the third player gets 4 in a row
+			x := i
+			if player != TRIANGLE {
+				x = i * 2
+			}
+			if _, err := game.HandlePlayerTurn(Move{X: x, Y: j * 2, Player: player}); err != nil {
+				t.Fatalf("Move by %s was rejected: %s", player, err)
+			}
+		}
+	}
+	if game.State.Status != PLAYER_WON || game.State.Winner != TRIANGLE {
+		t.Errorf("Third player should have won but status was %s with winner %s", game.State.Status, game.State.Winner)
+	}
+	game, _ = New(GameOptions{Size: 10, Players: 4, Marks: map[PlayerSymbol]string{SQUARE: "#"}})
+	if game.mark(SQUARE) != "#" || game.mark(TRIANGLE) != TRIANGLE.String() {
+		t.Errorf("Configured marks weren't used, got %s and %s", game.mark(SQUARE), game.mark(TRIANGLE))
+	}
+}
+
+func TestTorusGame(t *testing.T) {
+	if _, err := New(GameOptions{Unbounded: true, Topology: TORUS}); err == nil {
+		t.Error("Was able to create an unbounded torus")
+	}
+	game := startGame(t, GameOptions{Size: 6, WinLength: 4, Topology: TORUS})
+	// X wins with a diagonal from (4, 4) through (5, 5) and (0, 0) to (1, 1)
+	for i, x := range []int{4, 5, 0, 1} {
+		game.HandlePlayerTurn(Move{X: x, Y: x, Player: X})
+		if i < 3 {
+			game.HandlePlayerTurn(Move{X: x, Y: 2, Player: O})
+		}
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won with a diagonal wrapping around the corner but status was %s", game.State.Status)
+	}
+}
+
+func TestGame3D(t *testing.T) {
+	if _, err := New(GameOptions{Size: 4, Depth: 4, WinLength: 4, Gravity: true}); err == nil {
+		t.Error("Was able to create a 3D game with gravity")
+	} else if _, err := New(GameOptions{Size: 15, Depth: 15, RuleSet: RENJU}); err == nil {
+		t.Error("Was able to create a 3D game with renju rules")
+	}
+	game := startGame(t, GameOptions{Size: 4, Depth: 4, WinLength: 4})
+	if _, err := game.HandlePlayerTurn(Move{X: 0, Y: 0, Z: 4, Player: X}); err == nil {
+		t.Error("Was able to place a stone outside the layers of the board")
+	}
+	// X wins Qubic with a line falling from the last layer to the first
+	for z := 3; z >= 0; z-- {
+		if _, err := game.HandlePlayerTurn(Move{X: 3 - z, Y: 0, Z: z, Player: X}); err != nil {
+			t.Fatal("Move was rejected", err)
+		}
+		if z > 0 {
+			game.HandlePlayerTurn(Move{X: 0, Y: 3, Z: z, Player: O})
+		}
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won with a line through the layers but status was %s", game.State.Status)
+	}
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Anything HandlePlayerTurn accepts, either a Move or a ColumnMove
+type PlayerTurn interface {
+	toMove(g *GameState) (Move, error)
+}
+
+// Drops a stone to the lowest empty cell of the column when the game is played with gravity
+type ColumnMove struct {
+	Column int
+	Player PlayerSymbol
+}
+
+func (m Move) toMove(g *GameState) (Move, error) {
+	if g.opts.Gravity && g.Board.isWithinBoard(m.X, m.Y, 0) {
+		if y, ok := g.Board.dropRow(m.X); !ok {
+			return m, fmt.Errorf("column %d is full", m.X)
+		} else if y != m.Y {
+			return m, fmt.Errorf("stone in column %d would drop to (%d, %d)", m.X, m.X, y)
+		}
+	}
+	return m, nil
+}
+
+func (m ColumnMove) toMove(g *GameState) (Move, error) {
+	if !g.opts.Gravity {
+		return Move{}, errors.New("column moves can only be played with gravity")
+	} else if m.Column < 0 || m.Column >= g.Board.width {
+		return Move{}, fmt.Errorf("column %d wasn't inside the board", m.Column)
+	}
+	y, ok := g.Board.dropRow(m.Column)
+	if !ok {
+		return Move{}, fmt.Errorf("column %d is full", m.Column)
+	}
+	return Move{X: m.Column, Y: y, Player: m.Player}, nil
+}
+
+// Returns the row a stone dropped into column x lands on, ie the last empty cell before the first
+// occupied or blocked one, or false if the column is full. Stones enter the column at its first
+// playable cell so that blocked cells above a masked board's shape don't fill the column.
+func (b *Board) dropRow(x int) (int, bool) {
+	y := 0
+	for y < b.height && b.getCellAt(x, y, 0).owner == BLOCKED {
+		y += 1
+	}
+	if y == b.height || b.getCellAt(x, y, 0).owner != EMPTY {
+		return 0, false
+	}
+	for y+1 < b.height && b.getCellAt(x, y+1, 0).owner == EMPTY {
+		y += 1
+	}
+	return y, true
+}
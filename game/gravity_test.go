@@ -0,0 +1,114 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestDropRow(t *testing.T) {
+	board := newBoard(3, 4, 3)
+	if y, ok := board.dropRow(1); !ok || y != 3 {
+		t.Errorf("Stone in an empty column should drop to row 3 but got %d", y)
+	}
+	board.updateCell(1, 3, 0, X)
+	board.updateCell(1, 2, 0, O)
+	if y, ok := board.dropRow(1); !ok || y != 1 {
+		t.Errorf("Stone should land on top of the column at row 1 but got %d", y)
+	}
+	board.updateCell(1, 1, 0, X)
+	board.updateCell(1, 0, 0, O)
+	if _, ok := board.dropRow(1); ok {
+		t.Error("Full column should have no row to drop to")
+	}
+
+	// Column 1 of the diamond is playable from row 2 to 4
+	board = newBoard(7, 7, 4)
+	board.applyMask(DiamondMask(7, 7))
+	if y, ok := board.dropRow(1); !ok || y != 4 {
+		t.Errorf("Stone should drop past the blocked cells to row 4 but got %d", y)
+	}
+	for y := 4; y >= 2; y-- {
+		board.updateCell(1, y, 0, X)
+	}
+	if _, ok := board.dropRow(1); ok {
+		t.Error("Column whose playable cells are taken should have no row to drop to")
+	}
+	// The middle cell is the only playable one of column 0
+	board.blockCell(0, 3, 0)
+	if _, ok := board.dropRow(0); ok {
+		t.Error("Column without playable cells should have no row to drop to")
+	}
+}
+
+func TestGravityGame(t *testing.T) {
+	if _, err := New(GameOptions{Unbounded: true, Gravity: true}); err == nil {
+		t.Error("Was able to create an unbounded game with gravity")
+	}
+	if _, err := New(GameOptions{Width: 7, Height: 6, Gravity: true, RuleSet: PENTE}); err == nil {
+		t.Error("Was able to create a PENTE game with gravity")
+	}
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3})
+	if _, err := game.HandlePlayerTurn(ColumnMove{Column: 0, Player: X}); err == nil {
+		t.Error("Was able to play a column move without gravity")
+	}
+
+	game = startGame(t, GameOptions{Size: 7, WinLength: 4, Gravity: true, Mask: DiamondMask(7, 7)})
+	if result, err := game.HandlePlayerTurn(ColumnMove{Column: 1, Player: X}); err != nil {
+		t.Error("Column whose top cell is blocked was rejected", err)
+	} else if result.Move != (Move{X: 1, Y: 4, Player: X}) {
+		t.Errorf("Stone should have dropped to (1, 4) but move was %v", result.Move)
+	}
+
+	game = startGame(t, GameOptions{Width: 7, Height: 6, WinLength: 4, Gravity: true})
+	result, err := game.HandlePlayerTurn(ColumnMove{Column: 3, Player: X})
+	if err != nil {
+		t.Fatal("Column move was rejected", err)
+	} else if result.Move != (Move{X: 3, Y: 5, Player: X}) {
+		t.Errorf("Stone should have dropped to (3, 5) but move was %v", result.Move)
+	}
+	if _, err := game.HandlePlayerTurn(Move{X: 3, Y: 0, Player: O}); err == nil {
+		t.Error("Was able to place a stone in mid-air")
+	}
+	if _, err := game.HandlePlayerTurn(Move{X: 3, Y: 4, Player: O}); err != nil {
+		t.Error("Wasn't able to place a stone on top of the column", err)
+	}
+	if _, err := game.HandlePlayerTurn(ColumnMove{Column: 7, Player: X}); err == nil {
+		t.Error("Was able to drop a stone outside the board")
+	}
+	for i := 0; i < 4; i++ {
+		game.HandlePlayerTurn(ColumnMove{Column: 3, Player: X})
+		game.HandlePlayerTurn(ColumnMove{Column: 3, Player: O})
+	}
+	if _, err := game.HandlePlayerTurn(ColumnMove{Column: 3, Player: X}); err == nil {
+		t.Error("Was able to drop a stone into a full column")
+	}
+	if _, err := game.HandlePlayerTurn(Move{X: 3, Y: 0, Player: X}); err == nil {
+		t.Error("Was able to place a stone into a full column")
+	}
+	// X wins with a diagonal built by dropping stones on each other
+	//
+	//  | | | | | | |
+	// --------------
+	//  | | | | | | |
+	// --------------
+	//  | | |X| | | |
+	// --------------
+	//  | |X|O| | | |
+	// --------------
+	//  |X|O|O| | | |
+	// --------------
+	// X|O|O|X| | |X|
+	//
+	game = startGame(t, GameOptions{Width: 7, Height: 6, WinLength: 4, Gravity: true})
+	for _, column := range []int{0, 1, 1, 2, 3, 2, 6, 3, 2, 3, 3} {
+		player := X
+		if game.State.Status == O_TURN {
+			player = O
+		}
+		if _, err := game.HandlePlayerTurn(ColumnMove{Column: column, Player: player}); err != nil {
+			t.Fatalf("Column move to %d was rejected: %s", column, err)
+		}
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won with a diagonal but status was %s", game.State.Status)
+	}
+}
@@ -0,0 +1,105 @@
+package game
+
+import (
+	"errors"
+)
+
+// Move in the history of the game with what's needed to take it back
+type HistoryEntry struct {
+	MoveResult
+	previous turnState
+	// Stones the move captured with their owners
+	captured []BoardCell
+}
+
+// State of the game before a move that the move might change
+type turnState struct {
+	status    GameStatus
+	turn      PlayerSymbol
+	winner    PlayerSymbol
+	chooser   PlayerSymbol
+	winReason WinReason
+	captures  int
+	target    *Coord
+}
+
+func (g *GameState) turnState() turnState {
+	return turnState{
+		status:    g.Status,
+		turn:      g.Turn,
+		winner:    g.Winner,
+		chooser:   g.Chooser,
+		winReason: g.WinReason,
+		captures:  g.Captures[g.Turn],
+		target:    g.Target,
+	}
+}
+
+// Colours chosen in a swap opening can't be taken back so neither can the moves before them
+func (g *GameState) lockHistory() {
+	g.undoLimit = len(g.MoveHistory)
+	g.undone = nil
+}
+
+// Removes the stone of the move, puts back the stones it captured and restores the state before it
+func (g *GameState) undoMove(entry HistoryEntry) {
+	move := entry.Move
+	if g.opts.Ultimate {
+		c, local, _ := g.localMove(move)
+		g.localBoard(c).removeCell(local.X, local.Y, 0)
+		// Moves can't be made in decided local boards so the move must have decided it
+		g.Board.removeCell(c.X, c.Y, 0)
+		g.Board.unblockCell(c.X, c.Y, 0)
+	} else {
+		g.Board.removeCell(move.X, move.Y, move.Z)
+	}
+	for _, cell := range entry.captured {
+		g.Board.updateCell(cell.x, cell.y, cell.z, cell.owner)
+	}
+	previous := entry.previous
+	g.Status = previous.status
+	g.Turn = previous.turn
+	g.Winner = previous.winner
+	g.Chooser = previous.chooser
+	g.WinReason = previous.winReason
+	g.Captures[move.Player] = previous.captures
+	g.Target = previous.target
+	g.placed -= 1
+}
+
+// Takes back the last move
+func (t *TicTacToe) Undo() error {
+	if t.Opts.DisableUndo {
+		return errors.New("undo is disabled in this game")
+	} else if len(t.State.MoveHistory) <= t.State.undoLimit {
+		return errors.New("no moves to undo")
+	}
+	entry := t.State.popMove()
+	t.State.undone = append(t.State.undone, entry.Move)
+	return nil
+}
+
+// Removes the last move from the history and takes it back
+func (g *GameState) popMove() HistoryEntry {
+	last := len(g.MoveHistory) - 1
+	entry := g.MoveHistory[last]
+	g.MoveHistory = g.MoveHistory[:last]
+	g.undoMove(entry)
+	return entry
+}
+
+// Plays again the last move taken back with Undo
+func (t *TicTacToe) Redo() (*MoveResult, error) {
+	if t.Opts.DisableUndo {
+		return nil, errors.New("redo is disabled in this game")
+	} else if len(t.State.undone) == 0 {
+		return nil, errors.New("no moves to redo")
+	}
+	last := len(t.State.undone) - 1
+	result, err := t.playMove(t.State.undone[last])
+	if err != nil {
+		return nil, err
+	}
+	t.State.undone = t.State.undone[:last]
+	return result, nil
+}
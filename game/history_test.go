@@ -0,0 +1,137 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Checks that the game is in the same state as a game that only played the given moves
+func checkSameState(t *testing.T, game *TicTacToe, opts GameOptions, moves []Move) {
+	expected := startGame(t, opts)
+	placeOpeningStones(t, expected, moves)
+	if !reflect.DeepEqual(game.State.Board.cells, expected.State.Board.cells) {
+		t.Errorf("Board after undo was\n%s\nbut should have been\n%s", game.State.Board.asStateString(), expected.State.Board.asStateString())
+	}
+	if game.State.Status != expected.State.Status || game.State.Winner != expected.State.Winner || game.State.WinReason != expected.State.WinReason {
+		t.Errorf("Status after undo was %s but should have been %s", game.State.Status, expected.State.Status)
+	}
+	if len(game.State.MoveHistory) != len(moves) {
+		t.Errorf("History should have had %d moves but had %d", len(moves), len(game.State.MoveHistory))
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	opts := GameOptions{Size: 5, WinLength: 3}
+	game := startGame(t, opts)
+	if err := game.Undo(); err == nil {
+		t.Error("Was able to undo before any moves were made")
+	}
+	moves := []Move{
+		{X: 1, Y: 1, Player: X},
+		{X: 0, Y: 0, Player: O},
+		{X: 2, Y: 2, Player: X},
+		{X: 4, Y: 4, Player: O},
+		{X: 1, Y: 2, Player: X},
+		{X: 1, Y: 3, Player: O},
+		{X: 3, Y: 3, Player: X},
+	}
+	placeOpeningStones(t, game, moves)
+	if game.State.Status != X_WON {
+		t.Fatalf("X should have won but status was %s", game.State.Status)
+	}
+	for i := 1; i <= 3; i++ {
+		if err := game.Undo(); err != nil {
+			t.Fatal("Undo failed", err)
+		}
+		checkSameState(t, game, opts, moves[:len(moves)-i])
+	}
+	result, err := game.Redo()
+	if err != nil {
+		t.Fatal("Redo failed", err)
+	} else if result.Move != moves[4] {
+		t.Errorf("Redo should have played %v but played %v", moves[4], result.Move)
+	}
+	checkSameState(t, game, opts, moves[:5])
+	// A new move replaces the moves that could have been redone
+	placeOpeningStones(t, game, []Move{{X: 3, Y: 0, Player: O}})
+	if _, err := game.Redo(); err == nil {
+		t.Error("Was able to redo after making a new move")
+	}
+	// Redoing into an ended game leaves the board as it was
+	game = startGame(t, opts)
+	placeOpeningStones(t, game, moves[:1])
+	if err := game.Undo(); err != nil {
+		t.Fatal("Undo failed", err)
+	}
+	game.EndGame()
+	if _, err := game.Redo(); err == nil {
+		t.Error("Was able to redo after the game ended")
+	} else if game.State.Board.getCellAt(1, 1, 0).owner != EMPTY || game.State.Board.emptyCells != 25 {
+		t.Error("Failed redo changed the board")
+	}
+
+	game = startGame(t, GameOptions{Size: 5, WinLength: 3, DisableUndo: true})
+	placeOpeningStones(t, game, moves[:1])
+	if err := game.Undo(); err == nil {
+		t.Error("Was able to undo with undo disabled")
+	}
+}
+
+func TestUndoCapture(t *testing.T) {
+	opts := GameOptions{Size: 10, RuleSet: PENTE}
+	game := startGame(t, opts)
+	moves := []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 1, Y: 0, Player: O},
+		{X: 9, Y: 9, Player: X},
+		{X: 2, Y: 0, Player: O},
+		{X: 3, Y: 0, Player: X},
+	}
+	placeOpeningStones(t, game, moves)
+	if game.State.Captures[X] != 1 {
+		t.Fatalf("X should have captured a pair but had %d captures", game.State.Captures[X])
+	}
+	game.Undo()
+	checkSameState(t, game, opts, moves[:4])
+	if game.State.Captures[X] != 0 {
+		t.Errorf("Undo should have given the pair back but X had %d captures", game.State.Captures[X])
+	}
+}
+
+func TestUndoOpening(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 15, Opening: SWAP})
+	placeOpeningStones(t, game, []Move{
+		{X: 7, Y: 7, Player: X},
+		{X: 8, Y: 7, Player: O},
+		{X: 7, Y: 8, Player: X},
+	})
+	if err := game.Undo(); err != nil || game.State.Status != X_TURN {
+		t.Fatalf("Undo should have taken back the third stone but status was %s", game.State.Status)
+	}
+	placeOpeningStones(t, game, []Move{{X: 6, Y: 8, Player: X}})
+	game.ChooseColour(O)
+	if err := game.Undo(); err == nil {
+		t.Error("Was able to undo a stone placed before the colours were chosen")
+	}
+}
+
+func TestUndoUltimate(t *testing.T) {
+	opts := GameOptions{Size: 2, WinLength: 2, Ultimate: true}
+	game := startGame(t, opts)
+	moves := []Move{
+		{X: 1, Y: 0, Player: X},
+		{X: 2, Y: 1, Player: O},
+		{X: 0, Y: 2, Player: X},
+		{X: 0, Y: 1, Player: O},
+		{X: 1, Y: 2, Player: X},
+	}
+	placeOpeningStones(t, game, moves)
+	if game.State.Board.getCellAt(0, 1, 0).owner != X {
+		t.Fatal("X should have claimed the bottom-left local board")
+	}
+	game.Undo()
+	checkSameState(t, game, opts, moves[:4])
+	if game.State.Board.getCellAt(0, 1, 0).owner != EMPTY || *game.State.Target != (Coord{0, 1, 0}) {
+		t.Errorf("Undo should have given back the local board and the target but target was %v", game.State.Target)
+	}
+}
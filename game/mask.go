@@ -0,0 +1,44 @@
+package game
+
+import "math"
+
+// Returns a mask for GameOptions.Mask with every cell of a width x height board playable
+func FullMask(width int, height int) [][]bool {
+	mask := make([][]bool, height)
+	for y := range mask {
+		mask[y] = make([]bool, width)
+		for x := range mask[y] {
+			mask[y][x] = true
+		}
+	}
+	return mask
+}
+
+// Returns a mask where only the diamond touching the middle of each edge of the board is playable
+func DiamondMask(width int, height int) [][]bool {
+	mask := FullMask(width, height)
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	for y := range mask {
+		for x := range mask[y] {
+			dx := math.Abs(float64(x)-cx) / (cx + 0.5)
+			dy := math.Abs(float64(y)-cy) / (cy + 0.5)
+			mask[y][x] = dx+dy <= 1
+		}
+	}
+	return mask
+}
+
+// Returns a mask where only a horizontal and a vertical band of the given thickness crossing at the
+// centre of the board are playable
+func CrossMask(width int, height int, thickness int) [][]bool {
+	mask := FullMask(width, height)
+	cx, cy := float64(width-1)/2, float64(height-1)/2
+	for y := range mask {
+		for x := range mask[y] {
+			inColumn := math.Abs(float64(x)-cx)*2 < float64(thickness)
+			inRow := math.Abs(float64(y)-cy)*2 < float64(thickness)
+			mask[y][x] = inColumn || inRow
+		}
+	}
+	return mask
+}
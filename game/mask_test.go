@@ -0,0 +1,130 @@
+package game
+
+import (
+	"testing"
+)
+
+func maskString(mask [][]bool) string {
+	s := ""
+	for _, row := range mask {
+		for _, playable := range row {
+			if playable {
+				s += "."
+			} else {
+				s += "#"
+			}
+		}
+		s += "\n"
+	}
+	return s
+}
+
+func TestMasks(t *testing.T) {
+	diamond := "" +
+		"##.##\n" +
+		"#...#\n" +
+		".....\n" +
+		"#...#\n" +
+		"##.##\n"
+	if s := maskString(DiamondMask(5, 5)); s != diamond {
+		t.Errorf("5x5 diamond mask was\n%s", s)
+	}
+	cross := "" +
+		"##..##\n" +
+		"##..##\n" +
+		"......\n" +
+		"......\n" +
+		"##..##\n" +
+		"##..##\n"
+	if s := maskString(CrossMask(6, 6, 2)); s != cross {
+		t.Errorf("6x6 cross mask was\n%s", s)
+	}
+}
+
+func TestBlockedCells(t *testing.T) {
+	board := newBoard(5, 1, 3)
+	board.blockCell(2, 0, 0)
+	if board.emptyCells != 4 {
+		t.Errorf("Blocked cell should not be counted as empty, got %d empty cells", board.emptyCells)
+	}
+	board.updateCell(0, 0, 0, X)
+	board.updateCell(1, 0, 0, X)
+	board.updateCell(3, 0, 0, X)
+	CheckCellAdjancies(t, board, 1, 0, map[Adjacency]int{HORIZONTAL: 1})
+	CheckCellAdjancies(t, board, 3, 0, map[Adjacency]int{})
+	if board.hasOpenLine() {
+		t.Error("Line of 3 shouldn't fit on either side of the blocked cell")
+	}
+	board.removeCell(2, 0, 0)
+	if board.getCellAt(2, 0, 0).owner != BLOCKED {
+		t.Error("Removing a blocked cell should keep it blocked")
+	}
+
+	// O at 0,0 can't capture the two blocked cells it flanks
+	board = newBoard(4, 1, 4)
+	board.blockCell(1, 0, 0)
+	board.blockCell(2, 0, 0)
+	board.updateCell(3, 0, 0, O)
+	if captured := board.findCaptures(0, 0, 0, O); len(captured) != 0 {
+		t.Errorf("Blocked cells shouldn't be captured but got %v", captured)
+	}
+}
+
+func TestMaskedGame(t *testing.T) {
+	if _, err := New(GameOptions{Width: 4, Height: 3, WinLength: 3, Mask: FullMask(3, 3)}); err == nil {
+		t.Error("Was able to create a game with a mask not matching the board")
+	} else if _, err := New(GameOptions{Unbounded: true, Mask: FullMask(3, 3)}); err == nil {
+		t.Error("Was able to create an unbounded game with a mask")
+	} else if _, err := New(GameOptions{Size: 15, Opening: PRO, Mask: CrossMask(15, 15, 0)}); err == nil {
+		t.Error("Was able to create a pro opening game with the centre blocked")
+	}
+
+	game := startGame(t, GameOptions{Size: 5, WinLength: 3, Mask: DiamondMask(5, 5)})
+	if _, err := game.HandlePlayerTurn(Move{X: 0, Y: 0, Player: X}); err == nil {
+		t.Error("Was able to place a stone on a blocked cell")
+	}
+	// X wins with a line through the middle of the diamond
+	moves := []Move{
+		{X: 2, Y: 2, Player: X},
+		{X: 1, Y: 1, Player: O},
+		{X: 2, Y: 1, Player: X},
+		{X: 3, Y: 1, Player: O},
+		{X: 2, Y: 3, Player: X},
+	}
+	for _, move := range moves {
+		if _, err := game.HandlePlayerTurn(move); err != nil {
+			t.Fatal("Move was rejected", move, err)
+		}
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won but status was %s", game.State.Status)
+	}
+
+	// Stones land on top of the blocked cell at the bottom of the middle column
+	mask := FullMask(3, 3)
+	mask[2][1] = false
+	game = startGame(t, GameOptions{Size: 3, WinLength: 3, Gravity: true, Mask: mask})
+	result, err := game.HandlePlayerTurn(ColumnMove{Column: 1, Player: X})
+	if err != nil {
+		t.Fatal("Column move was rejected", err)
+	} else if result.Move.Y != 1 {
+		t.Errorf("Stone should have landed on the blocked cell at row 1 but got %d", result.Move.Y)
+	}
+
+	// Cells below a blocked cell in the middle of a column could never be reached
+	mask = FullMask(3, 3)
+	mask[1][1] = false
+	if _, err := New(GameOptions{Size: 3, WinLength: 3, Gravity: true, Mask: mask}); err == nil {
+		t.Error("Was able to create a gravity game with a blocked cell in the middle of a column")
+	}
+
+	// Game ends in a tie once every playable cell is taken
+	mask = FullMask(3, 1)
+	mask[0][1] = false
+	game = startGame(t, GameOptions{Width: 3, Height: 1, WinLength: 2, Mask: mask})
+	game.HandlePlayerTurn(Move{X: 0, Y: 0, Player: X})
+	game.HandlePlayerTurn(Move{X: 2, Y: 0, Player: O})
+	if game.State.Status != TIE {
+		t.Errorf("Game should have been a tie but status was %s", game.State.Status)
+	}
+}
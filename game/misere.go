@@ -0,0 +1,35 @@
+package game
+
+// Checks whether placing the player's stone at x,y,z would make a winning line
+func (g *GameState) completesLine(x int, y int, z int, player PlayerSymbol) bool {
+	for _, dir := range g.Board.directions() {
+		count := len(g.Board.getAdjacentCells(x, y, z, player, dir)) + 1
+		if g.opts.RuleSet.isWinningLine(count, g.Board.winLength, player) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks whether the player has a move that doesn't make a line, misère games are otherwise lost
+// by the player no matter where they move
+func (g *GameState) hasSafeMove(player PlayerSymbol) bool {
+	if g.Board.unbounded {
+		return true
+	}
+	for _, cell := range g.Board.cells {
+		if cell.owner != EMPTY {
+			continue
+		}
+		// With gravity only the cells stones drop to can be played
+		if g.opts.Gravity {
+			if y, _ := g.Board.dropRow(cell.x); y != cell.y {
+				continue
+			}
+		}
+		if !g.completesLine(cell.x, cell.y, cell.z, player) {
+			return true
+		}
+	}
+	return false
+}
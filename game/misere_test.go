@@ -0,0 +1,56 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestMisereGame(t *testing.T) {
+	if _, err := New(GameOptions{Size: 5, Misere: true, Players: 3}); err == nil {
+		t.Error("Was able to create a misère game for three players")
+	}
+	game := startGame(t, GameOptions{Size: 5, WinLength: 3, Misere: true})
+	for _, move := range []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 0, Y: 4, Player: O},
+		{X: 1, Y: 0, Player: X},
+		{X: 2, Y: 4, Player: O},
+		{X: 2, Y: 0, Player: X},
+	} {
+		game.HandlePlayerTurn(move)
+	}
+	if game.State.Status != O_WON || game.State.WinReason != OPPONENT_LINE {
+		t.Errorf("O should have won by X's line but status was %s with reason %s", game.State.Status, game.State.WinReason)
+	}
+}
+
+func TestForcedLine(t *testing.T) {
+	// After O fills the bottom middle cell X can only play the centre, which makes both diagonals.
+	// Had O played the centre instead, O would have made the middle row.
+	//
+	// X|O|X
+	// -----
+	// O| |O
+	// -----
+	// X| |X
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3, Misere: true})
+	for _, move := range []Move{
+		{X: 0, Y: 0, Player: X},
+		{X: 1, Y: 0, Player: O},
+		{X: 2, Y: 0, Player: X},
+		{X: 0, Y: 1, Player: O},
+		{X: 0, Y: 2, Player: X},
+		{X: 2, Y: 1, Player: O},
+		{X: 2, Y: 2, Player: X},
+	} {
+		if _, err := game.HandlePlayerTurn(move); err != nil {
+			t.Fatal("Move was rejected", move, err)
+		}
+	}
+	if !game.State.hasSafeMove(O) {
+		t.Fatal("O should still have had a safe move")
+	}
+	result, _ := game.HandlePlayerTurn(Move{X: 1, Y: 2, Player: O})
+	if result.Status != O_WON || game.State.WinReason != FORCED_LINE {
+		t.Errorf("O should have won since X can only make a line but status was %s with reason %s", result.Status, game.State.WinReason)
+	}
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Opening int
+
+const (
+	// No restrictions, X starts anywhere
+	FREE_OPENING Opening = iota
+	// X starts in the centre and X's second stone must be at least 3 cells away from it
+	PRO
+	// Same as PRO but X's second stone must be at least 4 cells away from the centre
+	LONG_PRO
+	// The first player places 3 stones (X, O, X) after which the second player chooses their colour
+	SWAP
+	// Same as SWAP but the second player may instead place 2 more stones (O, X) and let the first player choose
+	SWAP2
+)
+
+func (o Opening) String() string {
+	return []string{"FREE_OPENING", "PRO", "LONG_PRO", "SWAP", "SWAP2"}[o]
+}
+
+var Openings = [...]Opening{FREE_OPENING, PRO, LONG_PRO, SWAP, SWAP2}
+
+// Returns the minimum distance of X's second stone from the centre
+func (o Opening) proDistance() int {
+	switch o {
+	case PRO:
+		return 3
+	case LONG_PRO:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// Returns the number of stones placed before the colours are chosen
+func (o Opening) swapStones() int {
+	if o == SWAP || o == SWAP2 {
+		return 3
+	}
+	return 0
+}
+
+func (g *GameState) centre() Coord {
+	if g.Board.unbounded {
+		return Coord{0, 0, 0}
+	}
+	return Coord{g.Board.width / 2, g.Board.height / 2, g.Board.depth / 2}
+}
+
+func (g *GameState) checkOpening(move Move) error {
+	distance := g.opts.Opening.proDistance()
+	if distance == 0 {
+		return nil
+	}
+	centre := g.centre()
+	dx, dy, dz := move.X-centre.X, move.Y-centre.Y, move.Z-centre.Z
+	if dx < 0 {
+		dx = -dx
+	}
+	if dy < 0 {
+		dy = -dy
+	}
+	if dz < 0 {
+		dz = -dz
+	}
+	if g.placed == 0 && (dx != 0 || dy != 0 || dz != 0) {
+		return fmt.Errorf("%s opening must start from the centre (%d, %d)", g.opts.Opening, centre.X, centre.Y)
+	} else if g.placed == 2 && maxInt(maxInt(dx, dy), dz) < distance {
+		return fmt.Errorf("%s opening requires X's second stone to be at least %d cells from the centre", g.opts.Opening, distance)
+	}
+	return nil
+}
+
+// Moves to the colour choice once the stones of the swap opening have been placed
+func (g *GameState) updateOpening() {
+	if g.placed != g.openingStones || !g.isRunning() {
+		return
+	}
+	if g.openingStones != g.opts.Opening.swapStones() {
+		// The second player placed two more stones in swap2
+		g.Status = CHOOSE_COLOUR
+		g.Chooser = X
+	} else if g.opts.Opening == SWAP2 {
+		g.Status = CHOOSE_SWAP2_OPTION
+		g.Chooser = O
+	} else {
+		g.Status = CHOOSE_COLOUR
+		g.Chooser = O
+	}
+}
+
+func (g *GameState) isChoosing() bool {
+	return g.Status == CHOOSE_COLOUR || g.Status == CHOOSE_SWAP2_OPTION
+}
+
+// Picks the colour the player deciding the swap opening continues with. The other player gets the
+// other colour and O places the next stone either way.
+func (t *TicTacToe) ChooseColour(colour PlayerSymbol) error {
+	if !t.State.isChoosing() {
+		return errors.New("colours can't be chosen now")
+	} else if colour != X && colour != O {
+		return fmt.Errorf("%s isn't a colour", colour)
+	}
+	if colour != t.State.Chooser {
+		t.swapPlayers()
+	}
+	t.State.openingStones = 0
+	t.State.Chooser = EMPTY
+	t.State.Placer = EMPTY
+	t.State.lockHistory()
+	t.State.setTurn(O)
+	return nil
+}
+
+// Instead of choosing a colour in swap2 the second player places one more O and X stone and
+// the first player chooses the colours
+func (t *TicTacToe) PlaceTwoStones() error {
+	if t.State.Status != CHOOSE_SWAP2_OPTION {
+		return errors.New("placing two stones is only possible after the first 3 stones of swap2")
+	}
+	t.State.openingStones = 5
+	t.State.Chooser = EMPTY
+	t.State.Placer = O
+	t.State.lockHistory()
+	t.State.setTurn(O)
+	return nil
+}
+
+func (t *TicTacToe) swapPlayers() {
+	t.XPlayer, t.OPlayer = t.OPlayer, t.XPlayer
+	t.XPlayer.Symbol = X
+	t.OPlayer.Symbol = O
+	t.Players[0], t.Players[1] = t.XPlayer, t.OPlayer
+}
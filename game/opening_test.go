@@ -0,0 +1,122 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestProOpening(t *testing.T) {
+	if _, err := New(GameOptions{Size: 5, Opening: PRO}); err == nil {
+		t.Error("Was able to create a pro opening game on a board too small for it")
+	}
+	tests := []struct {
+		opening  Opening
+		rejected Move
+		accepted Move
+	}{
+		{PRO, Move{X: 9, Y: 5, Player: X}, Move{X: 10, Y: 4, Player: X}},
+		{LONG_PRO, Move{X: 10, Y: 10, Player: X}, Move{X: 3, Y: 11, Player: X}},
+	}
+	for _, test := range tests {
+		game := startGame(t, GameOptions{Size: 15, Opening: test.opening})
+		if _, err := game.HandlePlayerTurn(Move{X: 0, Y: 0, Player: X}); err == nil {
+			t.Errorf("%s opening allowed X to start outside the centre", test.opening)
+		}
+		if _, err := game.HandlePlayerTurn(Move{X: 7, Y: 7, Player: X}); err != nil {
+			t.Errorf("%s opening didn't allow X to start from the centre: %s", test.opening, err)
+		}
+		if _, err := game.HandlePlayerTurn(Move{X: 8, Y: 8, Player: O}); err != nil {
+			t.Errorf("%s opening didn't allow O to play next to the centre: %s", test.opening, err)
+		}
+		if _, err := game.HandlePlayerTurn(test.rejected); err == nil {
+			t.Errorf("%s opening allowed X's second stone at %v", test.opening, test.rejected)
+		}
+		if _, err := game.HandlePlayerTurn(test.accepted); err != nil {
+			t.Errorf("%s opening didn't allow X's second stone at %v: %s", test.opening, test.accepted, err)
+		}
+		if _, err := game.HandlePlayerTurn(Move{X: 8, Y: 7, Player: O}); err != nil {
+			t.Errorf("%s opening restricted moves after the opening: %s", test.opening, err)
+		}
+	}
+}
+
+func placeOpeningStones(t *testing.T, game *TicTacToe, moves []Move) {
+	for _, move := range moves {
+		if _, err := game.HandlePlayerTurn(move); err != nil {
+			t.Fatalf("Opening move %v was rejected: %s", move, err)
+		}
+	}
+}
+
+func TestSwapOpening(t *testing.T) {
+	for _, colour := range []PlayerSymbol{X, O} {
+		game := startGame(t, GameOptions{Size: 15, Opening: SWAP})
+		first, second := game.XPlayer, game.OPlayer
+		placeOpeningStones(t, game, []Move{
+			{X: 7, Y: 7, Player: X},
+			{X: 8, Y: 7, Player: O},
+			{X: 7, Y: 8, Player: X},
+		})
+		if game.State.Status != CHOOSE_COLOUR || game.State.Chooser != O {
+			t.Fatalf("Swap opening should have O choose the colour but had status %s and chooser %s", game.State.Status, game.State.Chooser)
+		}
+		if _, err := game.HandlePlayerTurn(Move{X: 0, Y: 0, Player: O}); err == nil {
+			t.Error("Was able to move before the colours were chosen")
+		}
+		if err := game.PlaceTwoStones(); err == nil {
+			t.Error("Was able to place two stones in a swap opening")
+		}
+		if err := game.ChooseColour(colour); err != nil {
+			t.Fatal("Failed to choose colour", err)
+		}
+		if colour == X && (game.XPlayer != second || game.OPlayer != first) {
+			t.Error("Second player choosing X didn't swap the players")
+		} else if colour == O && (game.XPlayer != first || game.OPlayer != second) {
+			t.Error("Second player choosing O swapped the players")
+		}
+		if game.XPlayer.Symbol != X || game.OPlayer.Symbol != O {
+			t.Error("Players had wrong symbols after choosing colours")
+		}
+		if game.State.Status != O_TURN {
+			t.Errorf("O should place the fourth stone but status was %s", game.State.Status)
+		}
+		if err := game.ChooseColour(X); err == nil {
+			t.Error("Was able to choose colours twice")
+		}
+	}
+}
+
+func TestSwap2Opening(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 15, Opening: SWAP2})
+	first, second := game.XPlayer, game.OPlayer
+	placeOpeningStones(t, game, []Move{
+		{X: 7, Y: 7, Player: X},
+		{X: 8, Y: 7, Player: O},
+		{X: 7, Y: 8, Player: X},
+	})
+	if game.State.Status != CHOOSE_SWAP2_OPTION || game.State.Chooser != O {
+		t.Fatalf("Swap2 opening should have O choose an option but had status %s and chooser %s", game.State.Status, game.State.Chooser)
+	}
+	if err := game.PlaceTwoStones(); err != nil {
+		t.Fatal("Failed to place two stones", err)
+	}
+	placeOpeningStones(t, game, []Move{
+		{X: 9, Y: 7, Player: O},
+		{X: 6, Y: 6, Player: X},
+	})
+	if game.State.Status != CHOOSE_COLOUR || game.State.Chooser != X {
+		t.Fatalf("Swap2 opening should have X choose the colour but had status %s and chooser %s", game.State.Status, game.State.Chooser)
+	}
+	if err := game.ChooseColour(O); err != nil {
+		t.Fatal("Failed to choose colour", err)
+	}
+	if game.XPlayer != second || game.OPlayer != first {
+		t.Error("First player choosing O didn't swap the players")
+	}
+	placeOpeningStones(t, game, []Move{
+		{X: 10, Y: 7, Player: O},
+		{X: 0, Y: 0, Player: X},
+	})
+	if game.State.Status != O_TURN {
+		t.Errorf("Game should have continued normally after the opening but status was %s", game.State.Status)
+	}
+}
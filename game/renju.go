@@ -0,0 +1,143 @@
+package game
+
+import (
+	"fmt"
+)
+
+type Restriction int
+
+const (
+	DOUBLE_THREE Restriction = iota
+	DOUBLE_FOUR
+	OVERLINE
+)
+
+func (r Restriction) String() string {
+	return []string{"DOUBLE_THREE", "DOUBLE_FOUR", "OVERLINE"}[r]
+}
+
+// Returned by HandlePlayerTurn when X tries to make a move forbidden by the renju rules
+type ForbiddenMoveError struct {
+	Move        Move
+	Restriction Restriction
+}
+
+func (e *ForbiddenMoveError) Error() string {
+	return fmt.Sprintf("%s at (%d, %d) is forbidden by %s", e.Move.Player, e.Move.X, e.Move.Y, e.Restriction)
+}
+
+// Marks cells outside the board in lines extracted by lineThrough
+const wall = PlayerSymbol(255)
+
+// Returns the owners of the cells in a line through x,y with the cell at x,y owned by the player.
+// The cell at x,y is at index radius and the cells outside the board are marked with wall.
+func (b *Board) lineThrough(x int, y int, player PlayerSymbol, dir Adjacency, radius int) []PlayerSymbol {
+	line := make([]PlayerSymbol, 2*radius+1)
+	line[radius] = player
+	for _, topSide := range []bool{true, false} {
+		nowX, nowY, outside := x, y, false
+		for i := 1; i <= radius; i++ {
+			owner := wall
+			if !outside {
+				cell, err := b.getAdjacentInDirection(nowX, nowY, 0, dir, topSide)
+				if err == nil {
+					owner, nowX, nowY = cell.owner, cell.x, cell.y
+				} else {
+					outside = true
+				}
+			}
+			if topSide {
+				line[radius+i] = owner
+			} else {
+				line[radius-i] = owner
+			}
+		}
+	}
+	return line
+}
+
+// Returns the length of the player's run of stones through index i
+func runLength(line []PlayerSymbol, i int, player PlayerSymbol) int {
+	start, end := i, i
+	for start > 0 && line[start-1] == player {
+		start -= 1
+	}
+	for end < len(line)-1 && line[end+1] == player {
+		end += 1
+	}
+	return end - start + 1
+}
+
+// Returns the empty cells that would complete exactly winLength stones in a row through center
+func fivePoints(line []PlayerSymbol, center int, player PlayerSymbol, winLength int) []int {
+	var points []int
+	for i, owner := range line {
+		if owner != EMPTY || i < center-winLength || i > center+winLength {
+			continue
+		}
+		line[i] = player
+		if runLength(line, i, player) == winLength && runLength(line, center, player) == winLength {
+			points = append(points, i)
+		}
+		line[i] = EMPTY
+	}
+	return points
+}
+
+// Checks whether the five points form a straight four, which has two ends that both complete a five
+func isStraightFour(points []int, winLength int) bool {
+	return len(points) == 2 && points[1]-points[0] == winLength
+}
+
+func countFours(line []PlayerSymbol, center int, player PlayerSymbol, winLength int) int {
+	points := fivePoints(line, center, player, winLength)
+	if isStraightFour(points, winLength) {
+		return 1
+	}
+	return len(points)
+}
+
+// An open three is a three that can be turned into a straight four with one more stone.
+// Whether that stone would itself be forbidden is not checked.
+func hasOpenThree(line []PlayerSymbol, center int, player PlayerSymbol, winLength int) bool {
+	for i, owner := range line {
+		if owner != EMPTY || i < center-winLength+1 || i > center+winLength-1 {
+			continue
+		}
+		line[i] = player
+		straight := runLength(line, i, player) < winLength && isStraightFour(fivePoints(line, center, player, winLength), winLength)
+		line[i] = EMPTY
+		if straight {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the renju restriction a move by the player at x,y would break. A move that makes exactly
+// winLength in a row is always allowed.
+func (b *Board) forbiddenRestriction(x int, y int, player PlayerSymbol) (Restriction, bool) {
+	radius := 2 * b.winLength
+	threes, fours, overline := 0, 0, false
+	for _, dir := range Adjancies {
+		line := b.lineThrough(x, y, player, dir, radius)
+		run := runLength(line, radius, player)
+		if run == b.winLength {
+			return 0, false
+		} else if run > b.winLength {
+			overline = true
+		} else if count := countFours(line, radius, player, b.winLength); count > 0 {
+			fours += count
+		} else if hasOpenThree(line, radius, player, b.winLength) {
+			threes += 1
+		}
+	}
+	if overline {
+		return OVERLINE, true
+	} else if fours > 1 {
+		return DOUBLE_FOUR, true
+	} else if threes > 1 {
+		return DOUBLE_THREE, true
+	}
+	return 0, false
+}
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func newRenjuBoard(stones map[Coord]PlayerSymbol) *Board {
+	board := newBoard(15, 15, 5)
+	for c, player := range stones {
+		board.updateCell(c.X, c.Y, 0, player)
+	}
+	return board
+}
+
+func TestForbiddenRestriction(t *testing.T) {
+	tests := []struct {
+		name      string
+		stones    map[Coord]PlayerSymbol
+		move      Coord
+		forbidden bool
+		expected  Restriction
+	}{
+		{"double three", map[Coord]PlayerSymbol{
+			{5, 7, 0}: X, {6, 7, 0}: X,
+			{7, 5, 0}: X, {7, 6, 0}: X,
+		}, Coord{7, 7, 0}, true, DOUBLE_THREE},
+		{"split double three", map[Coord]PlayerSymbol{
+			{4, 7, 0}: X, {5, 7, 0}: X,
+			{7, 4, 0}: X, {7, 6, 0}: X,
+		}, Coord{7, 7, 0}, true, DOUBLE_THREE},
+		{"double three with one side blocked", map[Coord]PlayerSymbol{
+			{4, 7, 0}: O, {5, 7, 0}: X, {6, 7, 0}: X,
+			{7, 5, 0}: X, {7, 6, 0}: X,
+		}, Coord{7, 7, 0}, false, 0},
+		{"double three blocked by the edge", map[Coord]PlayerSymbol{
+			{1, 0, 0}: X, {2, 0, 0}: X,
+			{3, 1, 0}: X, {3, 2, 0}: X,
+		}, Coord{3, 0, 0}, false, 0},
+		{"double four", map[Coord]PlayerSymbol{
+			{3, 7, 0}: O, {4, 7, 0}: X, {5, 7, 0}: X, {6, 7, 0}: X,
+			{7, 4, 0}: X, {7, 5, 0}: X, {7, 6, 0}: X,
+		}, Coord{7, 7, 0}, true, DOUBLE_FOUR},
+		{"double four in one line", map[Coord]PlayerSymbol{
+			{0, 7, 0}: X, {1, 7, 0}: X, {2, 7, 0}: X,
+			{6, 7, 0}: X, {7, 7, 0}: X, {8, 7, 0}: X,
+		}, Coord{4, 7, 0}, true, DOUBLE_FOUR},
+		{"four and three", map[Coord]PlayerSymbol{
+			{4, 7, 0}: X, {5, 7, 0}: X, {6, 7, 0}: X,
+			{7, 5, 0}: X, {7, 6, 0}: X,
+		}, Coord{7, 7, 0}, false, 0},
+		{"overline", map[Coord]PlayerSymbol{
+			{2, 7, 0}: X, {3, 7, 0}: X, {4, 7, 0}: X, {6, 7, 0}: X, {7, 7, 0}: X,
+		}, Coord{5, 7, 0}, true, OVERLINE},
+		{"five with double three", map[Coord]PlayerSymbol{
+			{3, 7, 0}: X, {4, 7, 0}: X, {5, 7, 0}: X, {6, 7, 0}: X,
+			{7, 5, 0}: X, {7, 6, 0}: X,
+			{8, 8, 0}: X, {9, 9, 0}: X,
+		}, Coord{7, 7, 0}, false, 0},
+	}
+	for _, test := range tests {
+		board := newRenjuBoard(test.stones)
+		restriction, forbidden := board.forbiddenRestriction(test.move.X, test.move.Y, X)
+		if forbidden != test.forbidden || restriction != test.expected {
+			t.Errorf("%s: expected forbidden %v with %s but got %v with %s", test.name, test.forbidden, test.expected, forbidden, restriction)
+		}
+	}
+}
+
+func TestRenjuGame(t *testing.T) {
+	game, err := New(GameOptions{Size: 15, RuleSet: RENJU})
+	if err != nil {
+		t.Fatal("Failed to create game", err)
+	}
+	game.AddPlayer(HUMAN, User{ID: "1", name: "Player 1"})
+	game.AddPlayer(HUMAN, User{ID: "2", name: "Player 2"})
+	game.StartGame()
+	moves := []Move{
+		{X: 5, Y: 7, Player: X},
+		{X: 0, Y: 0, Player: O},
+		{X: 6, Y: 7, Player: X},
+		{X: 2, Y: 0, Player: O},
+		{X: 7, Y: 5, Player: X},
+		{X: 4, Y: 0, Player: O},
+		{X: 7, Y: 6, Player: X},
+		{X: 6, Y: 0, Player: O},
+	}
+	for _, move := range moves {
+		if _, err := game.HandlePlayerTurn(move); err != nil {
+			t.Fatalf("Move %v was rejected: %s", move, err)
+		}
+	}
+	forbidden := game.State.ForbiddenCells()
+	if restriction, ok := forbidden[Coord{7, 7, 0}]; !ok || restriction != DOUBLE_THREE {
+		t.Errorf("Cell (7, 7) should have been listed as a double three but got %v", forbidden)
+	}
+	_, err = game.HandlePlayerTurn(Move{X: 7, Y: 7, Player: X})
+	var forbiddenErr *ForbiddenMoveError
+	if !errors.As(err, &forbiddenErr) || forbiddenErr.Restriction != DOUBLE_THREE {
+		t.Errorf("Double three should have been rejected with ForbiddenMoveError but got %v", err)
+	}
+	if game.State.Status != X_TURN || game.State.Board.getCellAt(7, 7, 0).owner != EMPTY {
+		t.Error("Forbidden move changed the game state")
+	}
+	// O isn't restricted
+	game.HandlePlayerTurn(Move{X: 14, Y: 14, Player: X})
+	game.HandlePlayerTurn(Move{X: 1, Y: 1, Player: O})
+	game.HandlePlayerTurn(Move{X: 13, Y: 14, Player: X})
+	if _, err := game.HandlePlayerTurn(Move{X: 1, Y: 0, Player: O}); err != nil {
+		t.Errorf("O should be allowed to make a double three but got %s", err)
+	}
+}
@@ -0,0 +1,112 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Where a player's turns come from, eg a human at the terminal, the AI or a script. The state given
+// to them is a copy so changing it doesn't change the game.
+type MoveSource interface {
+	// Returns the turn the player whose turn it is takes
+	NextTurn(g *GameState) (PlayerTurn, error)
+	// Returns the colour the chooser picks in a swap opening, EMPTY to place two more stones in swap2
+	ChooseColour(g *GameState) (PlayerSymbol, error)
+}
+
+// Turn that takes back the last move instead of placing a stone, or with Redo plays it again
+type HistoryTurn struct {
+	Redo bool
+}
+
+func (h HistoryTurn) toMove(g *GameState) (Move, error) {
+	return Move{}, errors.New("taking back a move doesn't place a stone")
+}
+
+// Plays the turns and chooses the colours it was given in order, eg to replay a game
+type ScriptedSource struct {
+	Turns   []PlayerTurn
+	Colours []PlayerSymbol
+}
+
+func (s *ScriptedSource) NextTurn(g *GameState) (PlayerTurn, error) {
+	if len(s.Turns) == 0 {
+		return nil, errors.New("script has run out of turns")
+	}
+	turn := s.Turns[0]
+	s.Turns = s.Turns[1:]
+	return turn, nil
+}
+
+func (s *ScriptedSource) ChooseColour(g *GameState) (PlayerSymbol, error) {
+	if len(s.Colours) == 0 {
+		return EMPTY, errors.New("script has run out of colours")
+	}
+	colour := s.Colours[0]
+	s.Colours = s.Colours[1:]
+	return colour, nil
+}
+
+// Asks the player whose turn it is, or who chooses the colours in a swap opening, for their turn
+// and plays it
+func (t *TicTacToe) PlayNextTurn() error {
+	if !t.isRunning() {
+		return errors.New("game has already ended")
+	}
+	player := t.turnPlayer()
+	if t.State.isChoosing() {
+		player = t.Players[t.State.Chooser-1]
+	}
+	if player.Source == nil {
+		return fmt.Errorf("%s has no source for their moves", player.User.name)
+	}
+	if t.State.isChoosing() {
+		colour, err := player.Source.ChooseColour(t.State.sourceCopy())
+		if err != nil {
+			return err
+		} else if colour == EMPTY {
+			return t.PlaceTwoStones()
+		}
+		return t.ChooseColour(colour)
+	}
+	turn, err := player.Source.NextTurn(t.State.sourceCopy())
+	if err != nil {
+		return err
+	}
+	if history, ok := turn.(HistoryTurn); ok {
+		if history.Redo {
+			_, err = t.Redo()
+			return err
+		}
+		return t.undoToHuman()
+	}
+	_, err = t.HandlePlayerTurn(turn)
+	return err
+}
+
+// Copy of the state for a move source that shares nothing it could change with the game
+func (g *GameState) sourceCopy() *GameState {
+	clone := g.clone()
+	clone.MoveHistory = append([]HistoryEntry(nil), g.MoveHistory...)
+	for i := range clone.MoveHistory {
+		clone.MoveHistory[i].captured = append([]BoardCell(nil), g.MoveHistory[i].captured...)
+	}
+	if g.Target != nil {
+		target := *g.Target
+		clone.Target = &target
+	}
+	return &clone
+}
+
+// Takes back the last move and the AI's moves before it so that it's a human's turn again
+func (t *TicTacToe) undoToHuman() error {
+	if err := t.Undo(); err != nil {
+		return err
+	}
+	for t.turnPlayer().Type == AI {
+		if t.Undo() != nil {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestScriptedGame(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3})
+	if err := game.PlayNextTurn(); err == nil {
+		t.Error("Was able to play a turn of a player without a move source")
+	}
+	game.XPlayer.Source = &ScriptedSource{Turns: []PlayerTurn{
+		Move{X: 0, Y: 0, Player: X},
+		Move{X: 1, Y: 0, Player: X},
+		Move{X: 2, Y: 0, Player: X},
+	}}
+	game.OPlayer.Source = &ScriptedSource{Turns: []PlayerTurn{
+		Move{X: 0, Y: 1, Player: O},
+		Move{X: 1, Y: 1, Player: O},
+	}}
+	for game.isRunning() {
+		if err := game.PlayNextTurn(); err != nil {
+			t.Fatal("Scripted turn failed", err)
+		}
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won but status was %s", game.State.Status)
+	}
+}
+
+// Plays the given number of turns with PlayNextTurn
+func playTurns(t *testing.T, game *TicTacToe, turns int) {
+	for i := 0; i < turns; i++ {
+		if err := game.PlayNextTurn(); err != nil {
+			t.Fatalf("Turn %d failed: %s", i+1, err)
+		}
+	}
+}
+
+// Source that tampers with the state it's given before playing its script
+type tamperingSource struct {
+	ScriptedSource
+}
+
+func (s *tamperingSource) NextTurn(g *GameState) (PlayerTurn, error) {
+	g.Board.updateCell(2, 2, 0, O)
+	if len(g.MoveHistory) > 0 {
+		g.MoveHistory[0].Move.X = 2
+	}
+	return s.ScriptedSource.NextTurn(g)
+}
+
+func TestSourceGetsCopy(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3})
+	game.XPlayer.Source = &ScriptedSource{Turns: []PlayerTurn{Move{X: 0, Y: 0, Player: X}}}
+	game.OPlayer.Source = &tamperingSource{ScriptedSource{Turns: []PlayerTurn{Move{X: 1, Y: 1, Player: O}}}}
+	playTurns(t, game, 2)
+	if game.State.Board.getCellAt(2, 2, 0).owner != EMPTY || game.State.MoveHistory[0].Move.X != 0 {
+		t.Error("Source was able to change the game through the state it was given")
+	}
+}
+
+func TestScriptedColours(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 15, Opening: SWAP})
+	first, second := game.XPlayer, game.OPlayer
+	// The first player places all three stones of the opening
+	first.Source = &ScriptedSource{Turns: []PlayerTurn{
+		Move{X: 7, Y: 7, Player: X},
+		Move{X: 8, Y: 7, Player: O},
+		Move{X: 7, Y: 8, Player: X},
+	}}
+	second.Source = &ScriptedSource{Colours: []PlayerSymbol{X}}
+	playTurns(t, game, 4)
+	if game.XPlayer != second || game.State.Status != O_TURN {
+		t.Errorf("Second player should have taken X and left O to move but status was %s", game.State.Status)
+	}
+
+	game = startGame(t, GameOptions{Size: 15, Opening: SWAP2})
+	first, second = game.XPlayer, game.OPlayer
+	first.Source = &ScriptedSource{
+		Turns: []PlayerTurn{
+			Move{X: 7, Y: 7, Player: X},
+			Move{X: 8, Y: 7, Player: O},
+			Move{X: 7, Y: 8, Player: X},
+		},
+		Colours: []PlayerSymbol{O},
+	}
+	// Instead of choosing the second player places one more O and X stone and leaves the choice to
+	// the first player
+	second.Source = &ScriptedSource{
+		Turns: []PlayerTurn{
+			Move{X: 6, Y: 6, Player: O},
+			Move{X: 9, Y: 9, Player: X},
+		},
+		Colours: []PlayerSymbol{EMPTY},
+	}
+	playTurns(t, game, 7)
+	if game.OPlayer != first || game.State.Status != O_TURN || len(game.State.MoveHistory) != 5 {
+		t.Errorf("First player should have taken O after 5 stones but status was %s", game.State.Status)
+	}
+}
+
+func TestUndoAgainstAI(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3})
+	game.XPlayer.Source = &ScriptedSource{Turns: []PlayerTurn{
+		Move{X: 1, Y: 1, Player: X},
+		HistoryTurn{},
+	}}
+	game.OPlayer.Type = AI
+	game.OPlayer.Source = NewLocalAI(EASY.Options())
+	for i := 0; i < 2; i++ {
+		if err := game.PlayNextTurn(); err != nil {
+			t.Fatal("Turn failed", err)
+		}
+	}
+	if len(game.State.MoveHistory) != 2 {
+		t.Fatalf("AI should have replied to X but history had %d moves", len(game.State.MoveHistory))
+	}
+	// Taking back X's move takes back the AI's reply too
+	if err := game.PlayNextTurn(); err != nil {
+		t.Fatal("Undo failed", err)
+	}
+	if len(game.State.MoveHistory) != 0 || game.State.Status != X_TURN {
+		t.Errorf("Undo should have taken back both moves but history had %d moves", len(game.State.MoveHistory))
+	}
+}
@@ -25,55 +25,317 @@ const (
 	X_WON
 	O_WON
 	TIE
+	// The player holding Chooser picks their colour in a swap opening
+	CHOOSE_COLOUR
+	// The player holding Chooser picks their colour or places two more stones in a swap2 opening
+	CHOOSE_SWAP2_OPTION
+	// Turn of a player other than X or O in games of more than two players
+	PLAYER_TURN
+	// A player other than X or O has won
+	PLAYER_WON
 )
 
 func (s GameStatus) String() string {
-	return []string{"NOT_STARTED", "X_TURN", "O_TURN", "X_WON", "O_WON", "TIE"}[s]
+	return []string{"NOT_STARTED", "X_TURN", "O_TURN", "X_WON", "O_WON", "TIE", "CHOOSE_COLOUR", "CHOOSE_SWAP2_OPTION", "PLAYER_TURN", "PLAYER_WON"}[s]
+}
+
+func turnStatus(player PlayerSymbol) GameStatus {
+	switch player {
+	case X:
+		return X_TURN
+	case O:
+		return O_TURN
+	default:
+		return PLAYER_TURN
+	}
+}
+
+func wonStatus(player PlayerSymbol) GameStatus {
+	switch player {
+	case X:
+		return X_WON
+	case O:
+		return O_WON
+	default:
+		return PLAYER_WON
+	}
+}
+
+type RuleSet int
+
+const (
+	// A line of WinLength or more wins
+	FREESTYLE RuleSet = iota
+	// Only a line of exactly WinLength wins, overlines don't count
+	STANDARD
+	// X wins only with exactly WinLength and loses by making an overline, O wins with WinLength or more
+	X_OVERLINE_LOSES
+	// Same as X_OVERLINE_LOSES but X isn't allowed to make double-threes, double-fours or overlines at all
+	RENJU
+	// Same as FREESTYLE but flanking a pair of enemy stones captures them and enough captures win the game
+	PENTE
+)
+
+func (r RuleSet) String() string {
+	return []string{"FREESTYLE", "STANDARD", "X_OVERLINE_LOSES", "RENJU", "PENTE"}[r]
+}
+
+var RuleSets = [...]RuleSet{FREESTYLE, STANDARD, X_OVERLINE_LOSES, RENJU, PENTE}
+
+func (r RuleSet) isWinningLine(count int, winLength int, player PlayerSymbol) bool {
+	switch r {
+	case STANDARD:
+		return count == winLength
+	case X_OVERLINE_LOSES, RENJU:
+		if player == X {
+			return count == winLength
+		}
+		return count >= winLength
+	default:
+		return count >= winLength
+	}
+}
+
+func (r RuleSet) isLosingLine(count int, winLength int, player PlayerSymbol) bool {
+	return r == X_OVERLINE_LOSES && player == X && count > winLength
+}
+
+type WinReason int
+
+const (
+	NOT_WON WinReason = iota
+	// Winner made a line of WinLength
+	LINE
+	// Winner captured CapturesToWin pairs
+	CAPTURES
+	// Loser made an overline that is not allowed
+	OPPONENT_OVERLINE
+	// Loser made a line of WinLength in a misère game
+	OPPONENT_LINE
+	// Loser had no move left in a misère game that wouldn't have made a line of WinLength
+	FORCED_LINE
+)
+
+func (r WinReason) String() string {
+	return []string{"NOT_WON", "LINE", "CAPTURES", "OPPONENT_OVERLINE", "OPPONENT_LINE", "FORCED_LINE"}[r]
 }
 
 type Move struct {
 	X      int
 	Y      int
+	Z      int
 	Player PlayerSymbol
 }
 
+type MoveResult struct {
+	Move Move
+	// Stones removed from the board by the move
+	Captured []Coord
+	Status   GameStatus
+}
+
 type GameState struct {
 	Board  Board
 	Status GameStatus
+	// Player whose turn it is
+	Turn PlayerSymbol
+	// Player who won the game, EMPTY while the game is running or if it ended in a tie
+	Winner PlayerSymbol
+	// Colour of the player who has to choose the colours during a swap opening
+	Chooser PlayerSymbol
+	// Colour of the player placing the stones of both colours during a swap opening, EMPTY once the
+	// colours have been chosen
+	Placer    PlayerSymbol
+	WinReason WinReason
+	// Number of pairs each player has captured
+	Captures map[PlayerSymbol]int
+	// Local boards of an ultimate game indexed by their cell on the meta board, which is then held in Board
+	LocalBoards []Board
+	// Local board the next stone has to be placed in, nil when the player is free to pick any open board
+	Target *Coord
+	// Moves made so far in the order they were made
+	MoveHistory []HistoryEntry
+	// Moves taken back with Undo, the last one is the first to be redone
+	undone []Move
+	// Number of moves at the start of the history that can't be undone anymore
+	undoLimit int
+	opts      GameOptions
+	// Number of stones placed so far
+	placed int
+	// Number of stones placed before the colours are chosen, 0 once the opening is over
+	openingStones int
+}
+
+func newState(opts GameOptions) *GameState {
+	var board *Board
+	if opts.Unbounded {
+		board = newUnboundedBoard(opts.WinLength)
+	} else {
+		board = newBoard3D(opts.Width, opts.Height, opts.Depth, opts.WinLength)
+		board.torus = opts.Topology == TORUS
+		board.applyMask(opts.Mask)
+	}
+	state := &GameState{
+		Board:         *board,
+		Status:        NOT_STARTED,
+		Captures:      map[PlayerSymbol]int{},
+		LocalBoards:   newLocalBoards(opts),
+		opts:          opts,
+		openingStones: opts.Opening.swapStones(),
+	}
+	if state.openingStones > 0 {
+		// The first player places the first stones of a swap opening
+		state.Placer = X
+	}
+	return state
+}
+
+// Copies the state so that moves can be made and taken back on the copy without touching the original
+func (g *GameState) clone() GameState {
+	clone := *g
+	clone.Board = g.Board.clone()
+	clone.Captures = make(map[PlayerSymbol]int, len(g.Captures))
+	for player, count := range g.Captures {
+		clone.Captures[player] = count
+	}
+	if g.LocalBoards != nil {
+		clone.LocalBoards = make([]Board, len(g.LocalBoards))
+		for i := range g.LocalBoards {
+			clone.LocalBoards[i] = g.LocalBoards[i].clone()
+		}
+	}
+	// Capping the capacity makes moves appended to the copy reallocate instead of overwriting the
+	// original's history
+	clone.MoveHistory = g.MoveHistory[:len(g.MoveHistory):len(g.MoveHistory)]
+	clone.undone = nil
+	return clone
 }
 
-func newState(size int) *GameState {
-	return &GameState{
-		Board:  *newBoard(size),
-		Status: NOT_STARTED,
+// Returns the mark shown for the player's symbol
+func (g *GameState) mark(player PlayerSymbol) string {
+	if mark, ok := g.opts.Marks[player]; ok {
+		return mark
 	}
+	return player.String()
+}
+
+func (g *GameState) isRunning() bool {
+	return g.isTurn() || g.isChoosing()
+}
+
+func (g *GameState) isTurn() bool {
+	return g.Status == X_TURN || g.Status == O_TURN || g.Status == PLAYER_TURN
+}
+
+func (g *GameState) setTurn(player PlayerSymbol) {
+	g.Turn = player
+	g.Status = turnStatus(player)
+}
+
+func (g *GameState) setWinner(player PlayerSymbol) {
+	g.Winner = player
+	g.Status = wonStatus(player)
+}
+
+// Players take turns in the order of their symbols
+func (g *GameState) nextPlayer(player PlayerSymbol) PlayerSymbol {
+	return player%PlayerSymbol(g.opts.Players) + 1
 }
 
 func (g *GameState) updateGameStatus(move Move) error {
-	status, playerWon := g.Status, g.CheckWin(move)
-	if playerWon && status == X_TURN {
-		status = X_WON
-	} else if playerWon && status == O_TURN {
-		status = O_WON
-		// } else if boardIsFull {
-		// status = TIE
-	} else if status == X_TURN {
-		status = O_TURN
-	} else if status == O_TURN {
-		status = X_TURN
-	} else {
+	if !g.isTurn() {
 		return errors.New("incorrect game state for changing player")
 	}
-	g.Status = status
+	reason := NOT_WON
+	if g.CheckWin(move) && g.opts.Misere {
+		reason = OPPONENT_LINE
+	} else if g.CheckWin(move) {
+		reason = LINE
+	} else if g.checkCaptureWin(move) {
+		reason = CAPTURES
+	} else if g.checkLoss(move) {
+		reason = OPPONENT_OVERLINE
+	}
+	if reason == LINE || reason == CAPTURES {
+		g.setWinner(move.Player)
+	} else if reason == OPPONENT_OVERLINE || reason == OPPONENT_LINE {
+		// Losing lines are only used in two player games
+		g.setWinner(g.nextPlayer(move.Player))
+	} else if g.isDrawn() {
+		g.Status = TIE
+	} else if g.opts.Misere && !g.hasSafeMove(g.nextPlayer(move.Player)) {
+		reason = FORCED_LINE
+		g.setWinner(move.Player)
+	} else {
+		g.setTurn(g.nextPlayer(move.Player))
+	}
+	g.WinReason = reason
 	return nil
 }
 
+// The game is drawn when the board fills up or, with EarlyDraw, when no line can be completed anymore
+func (g *GameState) isDrawn() bool {
+	return g.Board.isFull() || (g.opts.EarlyDraw && !g.Board.hasOpenLine())
+}
+
 func (g *GameState) CheckWin(lastMove Move) bool {
-	cell := g.Board.getCellAt(lastMove.X, lastMove.Y)
+	return g.hasWinningLine(&g.Board, lastMove)
+}
+
+// Checks whether the move made a winning line on the board, which in ultimate games is either the
+// meta board or one of the local boards
+func (g *GameState) hasWinningLine(b *Board, move Move) bool {
+	cell := b.getCellAt(move.X, move.Y, move.Z)
 	for _, count := range cell.adjacency {
-		if count == 5 {
+		if g.opts.RuleSet.isWinningLine(int(count), b.winLength, move.Player) {
 			return true
 		}
 	}
 	return false
 }
+
+// Checks whether the last move made a line that loses the game, eg an overline by X
+func (g *GameState) checkLoss(lastMove Move) bool {
+	cell := g.Board.getCellAt(lastMove.X, lastMove.Y, lastMove.Z)
+	for _, count := range cell.adjacency {
+		if g.opts.RuleSet.isLosingLine(int(count), g.Board.winLength, lastMove.Player) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GameState) checkForbidden(move Move) error {
+	if g.opts.RuleSet != RENJU || move.Player != X {
+		return nil
+	}
+	if restriction, forbidden := g.Board.forbiddenRestriction(move.X, move.Y, move.Player); forbidden {
+		return &ForbiddenMoveError{Move: move, Restriction: restriction}
+	}
+	return nil
+}
+
+// Lists the empty cells X isn't allowed to play in with the restriction each of them would break
+func (g *GameState) ForbiddenCells() map[Coord]Restriction {
+	forbidden := map[Coord]Restriction{}
+	if g.opts.RuleSet != RENJU {
+		return forbidden
+	}
+	min, max := g.Board.bounds()
+	if g.Board.unbounded {
+		// Only cells close enough to existing stones can break a restriction
+		min = Coord{min.X - g.Board.winLength, min.Y - g.Board.winLength, 0}
+		max = Coord{max.X + g.Board.winLength, max.Y + g.Board.winLength, 0}
+	}
+	for y := min.Y; y <= max.Y; y++ {
+		for x := min.X; x <= max.X; x++ {
+			if g.Board.getCellAt(x, y, 0).owner != EMPTY {
+				continue
+			}
+			if restriction, ok := g.Board.forbiddenRestriction(x, y, X); ok {
+				forbidden[Coord{x, y, 0}] = restriction
+			}
+		}
+	}
+	return forbidden
+}
@@ -1,58 +1,46 @@
 package game
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 )
 
-func Play() {
+var stdin = bufio.NewReader(os.Stdin)
+
+func Play(opts GameOptions) {
 	fmt.Println("### TicTac5 ###")
-	game := New(GameOptions{
-		Size: 5,
-	})
-	game.AddPlayer(HUMAN, User{
-		ID:   "me",
-		name: "Player",
-	})
-	game.AddPlayer(HUMAN, User{
-		ID:   "opponent",
-		name: "Opponent",
-	})
+	game, err := New(opts)
+	if err != nil {
+		fmt.Println("error", err)
+		return
+	}
+	for i := 1; i <= game.Opts.Players; i++ {
+		if game.Opts.GameType == LOCAL_AI && i == 2 {
+			player, _ := game.AddPlayer(AI, User{ID: "ai", name: "Computer"})
+			player.Source = NewLocalAI(game.Opts.AI)
+			continue
+		}
+		player, _ := game.AddPlayer(HUMAN, User{
+			ID:   fmt.Sprintf("player-%d", i),
+			name: fmt.Sprintf("Player %d", i),
+		})
+		player.Source = TerminalSource{}
+	}
 	game.StartGame()
 	for game.isRunning() {
 		PrintBoard(game)
-		var x, y int
-		var err error
-		if game.State.Status == X_TURN {
-			x, y, err = PromptMove()
-		} else {
-			x, y, err = PromptMove()
-		}
-		if err != nil {
+		if err := game.PlayNextTurn(); err != nil {
 			fmt.Println("error", err)
-			continue
-		}
-		var player PlayerSymbol
-		if game.State.Status == X_TURN {
-			player = X
-		} else {
-			player = O
-		}
-		move := Move{
-			X:      x,
-			Y:      y,
-			Player: player,
-		}
-		err = game.HandlePlayerTurn(move)
-		if err != nil {
-			fmt.Println("error from handlePlayerTurn", err)
-			continue
 		}
 	}
 	PrintBoard(game)
 	fmt.Println(game.State.Status.String())
+	if game.State.Winner != EMPTY {
+		fmt.Printf("%s won\n", game.mark(game.State.Winner))
+	}
 	fmt.Println("### GAME ENDED ###")
 }
 
@@ -62,25 +50,223 @@ func ClearScreen() {
 	c.Run()
 }
 
+// How many empty cells are shown around the occupied area of an unbounded board
+const viewportMargin = 2
+
+func viewport(b *Board) (Coord, Coord) {
+	min, max := b.bounds()
+	if b.unbounded {
+		min = Coord{min.X - viewportMargin, min.Y - viewportMargin, min.Z}
+		max = Coord{max.X + viewportMargin, max.Y + viewportMargin, max.Z}
+	}
+	return min, max
+}
+
 func PrintBoard(t *TicTacToe) {
 	ClearScreen()
-	for y := 0; y < t.Opts.Size; y++ {
-		for x := 0; x < t.Opts.Size; x++ {
-			cell := t.State.Board.getCellAt(x, y)
-			if cell.owner == EMPTY {
+	fmt.Printf("Get %d in a row to win\n", t.Opts.WinLength)
+	if t.Opts.Ultimate {
+		printUltimateBoard(t)
+		return
+	}
+	min, max := viewport(&t.State.Board)
+	if t.Opts.Topology == TORUS {
+		fmt.Println("Lines wrap around the edges of the board")
+	}
+	if t.Opts.Unbounded {
+		fmt.Printf("Showing x %d..%d, y %d..%d\n", min.X, max.X, min.Y, max.Y)
+	}
+	var forbidden map[Coord]Restriction
+	if t.State.Status == X_TURN {
+		forbidden = t.State.ForbiddenCells()
+	}
+	for z := min.Z; z <= max.Z; z++ {
+		if t.Opts.Depth > 1 {
+			fmt.Printf("Layer %d\n", z)
+		}
+		for y := min.Y; y <= max.Y; y++ {
+			for x := min.X; x <= max.X; x++ {
+				cell := t.State.Board.getCellAt(x, y, z)
+				if _, ok := forbidden[Coord{x, y, z}]; ok {
+					fmt.Printf("*|")
+				} else if cell.owner == EMPTY {
+					fmt.Printf(" |")
+				} else if cell.owner == BLOCKED {
+					fmt.Printf("#|")
+				} else {
+					fmt.Print(t.mark(cell.owner), "|")
+				}
+			}
+			fmt.Println()
+			fmt.Println(strings.Repeat("--", max.X-min.X+1))
+		}
+	}
+	if t.Opts.Gravity {
+		for x := min.X; x <= max.X; x++ {
+			fmt.Printf("%d ", x%10)
+		}
+		fmt.Println()
+	}
+	if t.Opts.RuleSet == PENTE {
+		fmt.Printf("Captured pairs (%d to win):", t.Opts.CapturesToWin)
+		for _, player := range t.Players {
+			fmt.Printf(" %s: %d", t.mark(player.Symbol), t.State.Captures[player.Symbol])
+		}
+		fmt.Println()
+	}
+	if len(forbidden) > 0 {
+		fmt.Println("X is not allowed to play in cells marked with *")
+	}
+}
+
+// Prints the local boards of an ultimate game side by side with double lines between them
+func printUltimateBoard(t *TicTacToe) {
+	g := &t.State
+	w, h := g.Board.width, g.Board.height
+	for y := 0; y < h*h; y++ {
+		for x := 0; x < w*w; x++ {
+			c, local, _ := g.localMove(Move{X: x, Y: y})
+			cell := g.localBoard(c).getCellAt(local.X, local.Y, 0)
+			mark := " "
+			if cell.owner != EMPTY {
+				mark = t.mark(cell.owner)
+			}
+			if x%w == w-1 {
+				fmt.Print(mark, "‖")
+			} else {
+				fmt.Print(mark, "|")
+			}
+		}
+		fmt.Println()
+		if y%h == h-1 {
+			fmt.Println(strings.Repeat("==", w*w))
+		} else {
+			fmt.Println(strings.Repeat("--", w*w))
+		}
+	}
+	fmt.Println("Local boards won:")
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			owner := g.Board.getCellAt(x, y, 0).owner
+			if owner == EMPTY {
 				fmt.Printf(" |")
+			} else if owner == BLOCKED {
+				fmt.Printf("#|")
 			} else {
-				fmt.Printf(cell.owner.String() + "|")
+				fmt.Print(t.mark(owner), "|")
 			}
 		}
 		fmt.Println()
-		fmt.Println(strings.Repeat("--", t.Opts.Size))
 	}
+	if g.Target != nil {
+		fmt.Printf("Next stone must be placed in the local board at (%d, %d)\n", g.Target.X, g.Target.Y)
+	}
+}
+
+// Reads the turns of a human playing at the terminal
+type TerminalSource struct{}
+
+func (TerminalSource) NextTurn(g *GameState) (PlayerTurn, error) {
+	for {
+		input, err := PromptInput(g, g.Turn)
+		if err != nil {
+			return nil, err
+		}
+		switch input {
+		case "u":
+			return HistoryTurn{}, nil
+		case "r":
+			return HistoryTurn{Redo: true}, nil
+		case "h":
+			printHints(g)
+			continue
+		}
+		return ParseTurn(g, g.Turn, input)
+	}
+}
+
+func printHints(g *GameState) {
+	fmt.Printf("Hints:")
+	for _, c := range g.Hints(3) {
+		if g.opts.Gravity {
+			// Stones are dropped by their column
+			fmt.Printf(" column %d", c.X)
+		} else if g.opts.Depth > 1 {
+			fmt.Printf(" (%d, %d, %d)", c.X, c.Y, c.Z)
+		} else {
+			fmt.Printf(" (%d, %d)", c.X, c.Y)
+		}
+	}
+	fmt.Println()
+}
+
+func (TerminalSource) ChooseColour(g *GameState) (PlayerSymbol, error) {
+	return PromptColour(g)
+}
+
+func PromptMove(g *GameState, player PlayerSymbol) (PlayerTurn, error) {
+	input, err := PromptInput(g, player)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTurn(g, player, input)
 }
 
-func PromptMove() (int, int, error) {
+// Asks the player for their move and returns the line they typed
+func PromptInput(g *GameState, player PlayerSymbol) (string, error) {
+	if g.opts.Gravity {
+		fmt.Printf("%s, enter column (eg 0)", g.mark(player))
+	} else if g.opts.Depth > 1 {
+		fmt.Printf("%s, enter x,y,z coordinates separated by space (eg 0 1 2)", g.mark(player))
+	} else {
+		fmt.Printf("%s, enter x,y coordinates separated by space (eg 0 1)", g.mark(player))
+	}
+	if !g.opts.DisableUndo {
+		fmt.Printf(", u to undo, r to redo")
+	}
+	fmt.Println(" or h for hints:")
+	return readLine()
+}
+
+// Parses a move typed in the format PromptInput asks for
+func ParseTurn(g *GameState, player PlayerSymbol, input string) (PlayerTurn, error) {
+	if g.opts.Gravity {
+		var column int
+		_, err := fmt.Sscanf(input, "%d", &column)
+		return ColumnMove{Column: column, Player: player}, err
+	} else if g.opts.Depth > 1 {
+		var readX, readY, readZ int
+		_, err := fmt.Sscanf(input, "%d %d %d", &readX, &readY, &readZ)
+		return Move{X: readX, Y: readY, Z: readZ, Player: player}, err
+	}
 	var readX, readY int
-	fmt.Println("Enter x,y coordinates separated by space (eg 0 1): ")
-	_, err := fmt.Scanf("%d %d", &readX, &readY)
-	return readX, readY, err
+	_, err := fmt.Sscanf(input, "%d %d", &readX, &readY)
+	return Move{X: readX, Y: readY, Player: player}, err
+}
+
+func readLine() (string, error) {
+	line, err := stdin.ReadString('\n')
+	return strings.TrimSpace(line), err
+}
+
+// Asks the chooser of a swap opening for their colour, EMPTY meaning two more stones in swap2
+func PromptColour(g *GameState) (PlayerSymbol, error) {
+	if g.Status == CHOOSE_SWAP2_OPTION {
+		fmt.Printf("%s, choose your colour (x or o) or place two more stones (2): \n", g.mark(g.Chooser))
+	} else {
+		fmt.Printf("%s, choose your colour (x or o): \n", g.mark(g.Chooser))
+	}
+	choice, err := readLine()
+	if err != nil {
+		return EMPTY, err
+	}
+	switch strings.ToLower(choice) {
+	case "x":
+		return X, nil
+	case "o":
+		return O, nil
+	case "2":
+		return EMPTY, nil
+	}
+	return EMPTY, fmt.Errorf("unknown choice %s", choice)
 }
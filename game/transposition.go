@@ -0,0 +1,92 @@
+package game
+
+// DefaultTableSize is used when AIOptions.TableSize is left unset
+const DefaultTableSize = 1 << 16
+
+// How the score stored for a position relates to its real score
+type Bound int
+
+const (
+	// The score is the real score of the position
+	EXACT Bound = iota
+	// The search was cut off by a move at least this good so the real score can be higher
+	LOWER_BOUND
+	// None of the moves reached alpha so the real score can be lower
+	UPPER_BOUND
+)
+
+func (b Bound) String() string {
+	return []string{"EXACT", "LOWER_BOUND", "UPPER_BOUND"}[b]
+}
+
+type tableEntry struct {
+	hash  uint64
+	score int
+	// Number of moves searched below the position, 0 for an unused entry
+	depth int
+	bound Bound
+	move  Move
+	// Set when the search of the position evaluated positions still being played at the depth limit
+	horizon bool
+	// Search that stored the entry, entries of earlier searches are replaced first
+	generation int
+}
+
+// Counts of the lookups made in the transposition table during a search
+type TableStats struct {
+	Probes int
+	// Lookups that found an entry for the position
+	Hits int
+	// Hits whose score was used without searching the position again
+	Cutoffs int
+}
+
+// Returns the share of the probes that found an entry
+func (s TableStats) HitRate() float64 {
+	if s.Probes == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Probes)
+}
+
+// Fixed size table of searched positions indexed by the low bits of their hash. When two positions
+// share a slot the one searched deeper is kept unless it's left over from an earlier search.
+type transpositionTable struct {
+	entries    []tableEntry
+	generation int
+	stats      TableStats
+}
+
+// Creates a table with size rounded up to a power of two entries
+func newTranspositionTable(size int) *transpositionTable {
+	n := 1
+	for n < size {
+		n *= 2
+	}
+	return &transpositionTable{entries: make([]tableEntry, n)}
+}
+
+// Starts a new search, the entries of earlier searches can then be replaced by shallower ones
+func (tt *transpositionTable) newSearch() {
+	tt.generation += 1
+	tt.stats = TableStats{}
+}
+
+func (tt *transpositionTable) probe(hash uint64) (tableEntry, bool) {
+	tt.stats.Probes += 1
+	entry := tt.entries[hash&uint64(len(tt.entries)-1)]
+	if entry.depth == 0 || entry.hash != hash {
+		return tableEntry{}, false
+	}
+	tt.stats.Hits += 1
+	return entry, true
+}
+
+func (tt *transpositionTable) store(entry tableEntry) {
+	slot := &tt.entries[entry.hash&uint64(len(tt.entries)-1)]
+	if slot.depth > entry.depth && slot.generation == tt.generation {
+		return
+	}
+	entry.generation = tt.generation
+	*slot = entry
+}
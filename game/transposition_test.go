@@ -0,0 +1,53 @@
+package game
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTranspositionTable(t *testing.T) {
+	tt := newTranspositionTable(5)
+	if len(tt.entries) != 8 {
+		t.Fatalf("Table should have been rounded up to 8 entries but had %d", len(tt.entries))
+	}
+	tt.newSearch()
+	tt.store(tableEntry{hash: 3, score: 10, depth: 4, bound: EXACT})
+	// Shares the slot with hash 3 but was searched less deep
+	tt.store(tableEntry{hash: 11, score: 20, depth: 2, bound: LOWER_BOUND})
+	if entry, ok := tt.probe(3); !ok || entry.score != 10 {
+		t.Errorf("Deeper entry should have been kept but got %+v", entry)
+	}
+	if _, ok := tt.probe(11); ok {
+		t.Error("Shallower entry shouldn't have replaced a deeper one")
+	}
+	if tt.stats.Probes != 2 || tt.stats.Hits != 1 || tt.stats.HitRate() != 0.5 {
+		t.Errorf("Table had wrong statistics %+v", tt.stats)
+	}
+	// Entries left over from an earlier search are replaced by anything
+	tt.newSearch()
+	tt.store(tableEntry{hash: 11, score: 20, depth: 2, bound: LOWER_BOUND})
+	if entry, ok := tt.probe(11); !ok || entry.bound != LOWER_BOUND {
+		t.Errorf("Entry of an earlier search should have been replaced but got %+v", entry)
+	}
+}
+
+func TestSearchUsesTable(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 4, WinLength: 3})
+	search := func(tableSize int) SearchResult {
+		result, err := NewLocalAI(AIOptions{Depth: 5, TableSize: tableSize}).Search(context.Background(), &game.State)
+		if err != nil {
+			t.Fatal("Search failed", err)
+		}
+		return result
+	}
+	tiny, full := search(1), search(DefaultTableSize)
+	if full.Table.Hits == 0 || full.Table.Cutoffs == 0 {
+		t.Errorf("Search should have found positions in the table but got %+v", full.Table)
+	}
+	if full.Nodes >= tiny.Nodes {
+		t.Errorf("Table should have saved moves but searched %d against %d without it", full.Nodes, tiny.Nodes)
+	}
+	if full.Score != tiny.Score {
+		t.Errorf("Table changed the score from %d to %d", tiny.Score, full.Score)
+	}
+}
@@ -0,0 +1,76 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+)
+
+func newLocalBoards(opts GameOptions) []Board {
+	if !opts.Ultimate {
+		return nil
+	}
+	boards := make([]Board, opts.Width*opts.Height)
+	for i := range boards {
+		boards[i] = *newBoard(opts.Width, opts.Height, opts.WinLength)
+	}
+	return boards
+}
+
+// Splits a move of an ultimate game, whose coordinates span all the local boards, into the cell of its
+// local board on the meta board and the move within the local board
+func (g *GameState) localMove(move Move) (Coord, Move, error) {
+	w, h := g.Board.width, g.Board.height
+	if move.X < 0 || move.Y < 0 || move.X >= w*w || move.Y >= h*h || move.Z != 0 {
+		return Coord{}, Move{}, errors.New("x, y wasn't inside the board")
+	}
+	return Coord{move.X / w, move.Y / h, 0}, Move{X: move.X % w, Y: move.Y % h, Player: move.Player}, nil
+}
+
+func (g *GameState) localBoard(c Coord) *Board {
+	return &g.LocalBoards[c.Y*g.Board.width+c.X]
+}
+
+// Places the stone on its local board and claims the local board's cell on the meta board if the stone
+// won it. Returns the move as it is made on the meta board.
+func (g *GameState) playLocal(move Move) (Move, error) {
+	c, local, err := g.localMove(move)
+	if err != nil {
+		return Move{}, err
+	} else if g.Target != nil && *g.Target != c {
+		return Move{}, fmt.Errorf("stone must be placed in the local board at (%d, %d)", g.Target.X, g.Target.Y)
+	} else if g.Board.getCellAt(c.X, c.Y, 0).owner != EMPTY {
+		return Move{}, fmt.Errorf("local board at (%d, %d) has already been decided", c.X, c.Y)
+	}
+	board := g.localBoard(c)
+	if board.getCellAt(local.X, local.Y, 0).owner != EMPTY {
+		return Move{}, errors.New("cell already selected")
+	}
+	board.updateCell(local.X, local.Y, 0, local.Player)
+	if g.hasWinningLine(board, local) {
+		g.Board.updateCell(c.X, c.Y, 0, local.Player)
+	} else if board.isFull() {
+		// Nobody can claim a drawn local board
+		g.Board.blockCell(c.X, c.Y, 0)
+	}
+	// The opponent has to play in the local board matching the cell the stone was placed in
+	g.Target = &Coord{local.X, local.Y, 0}
+	if g.Board.getCellAt(local.X, local.Y, 0).owner != EMPTY {
+		g.Target = nil
+	}
+	return Move{X: c.X, Y: c.Y, Player: move.Player}, nil
+}
+
+func (t *TicTacToe) handleUltimateTurn(move Move) (*MoveResult, error) {
+	metaMove, err := t.State.playLocal(move)
+	if err != nil {
+		return nil, err
+	}
+	t.State.placed += 1
+	if err := t.State.updateGameStatus(metaMove); err != nil {
+		return nil, err
+	}
+	return &MoveResult{
+		Move:   move,
+		Status: t.State.Status,
+	}, nil
+}
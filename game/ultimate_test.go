@@ -0,0 +1,75 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestUltimateGame(t *testing.T) {
+	if _, err := New(GameOptions{Size: 3, WinLength: 3, Ultimate: true, Gravity: true}); err == nil {
+		t.Error("Was able to create an ultimate game with gravity")
+	}
+	game := startGame(t, GameOptions{Size: 2, WinLength: 2, Ultimate: true})
+	// Local boards are 2x2 so the move at (1, 0) goes to the top-left local board and sends O to the
+	// top-right one
+	moves := []Move{
+		{X: 1, Y: 0, Player: X},
+		{X: 2, Y: 1, Player: O},
+		{X: 0, Y: 2, Player: X},
+		{X: 0, Y: 1, Player: O},
+		{X: 1, Y: 2, Player: X},
+		{X: 3, Y: 0, Player: O},
+	}
+	for i, move := range moves {
+		if i == 1 {
+			if _, err := game.HandlePlayerTurn(Move{X: 0, Y: 0, Player: O}); err == nil {
+				t.Error("Was able to play outside the target local board")
+			}
+		}
+		if _, err := game.HandlePlayerTurn(move); err != nil {
+			t.Fatal("Move was rejected", move, err)
+		}
+	}
+	// X claimed the bottom-left local board and O the top-right one, which X would have been sent to
+	if owner := game.State.Board.getCellAt(0, 1, 0).owner; owner != X {
+		t.Errorf("X should have claimed the bottom-left local board but it was owned by %s", owner)
+	} else if owner := game.State.Board.getCellAt(1, 0, 0).owner; owner != O {
+		t.Errorf("O should have claimed the top-right local board but it was owned by %s", owner)
+	} else if game.State.Target != nil {
+		t.Errorf("X should have been free to pick any board but target was %v", game.State.Target)
+	}
+	if _, err := game.HandlePlayerTurn(Move{X: 3, Y: 1, Player: X}); err == nil {
+		t.Error("Was able to play in a local board that had already been won")
+	}
+	if _, err := game.HandlePlayerTurn(Move{X: 0, Y: 0, Player: X}); err != nil {
+		t.Fatal("Move was rejected", err)
+	}
+	if game.State.Status != X_WON {
+		t.Errorf("X should have won with a column on the meta board but status was %s", game.State.Status)
+	}
+}
+
+func TestUltimateDrawnBoard(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 3, WinLength: 3, Ultimate: true})
+	// Fill the centre local board except for its centre cell so that the last stone draws it
+	//
+	// X|O|X
+	// X| |O
+	// O|X|O
+	board := game.State.localBoard(Coord{1, 1, 0})
+	for i, owner := range []PlayerSymbol{X, O, X, X, EMPTY, O, O, X, O} {
+		if owner != EMPTY {
+			board.updateCell(i%3, i/3, 0, owner)
+		}
+	}
+	result, err := game.HandlePlayerTurn(Move{X: 4, Y: 4, Player: X})
+	if err != nil {
+		t.Fatal("Move was rejected", err)
+	} else if result.Status != O_TURN {
+		t.Errorf("Game should have continued but status was %s", result.Status)
+	}
+	if owner := game.State.Board.getCellAt(1, 1, 0).owner; owner != BLOCKED {
+		t.Errorf("Drawn local board should have been blocked on the meta board but was %s", owner)
+	} else if game.State.Target != nil {
+		t.Error("O should be free to pick any board after being sent to a drawn one")
+	}
+}
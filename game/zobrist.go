@@ -0,0 +1,59 @@
+package game
+
+// Scrambles the bits of x so that keys derived from nearby values look unrelated
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// Random looking key of the owner of the cell at x,y,z. Keys are derived from the coordinates instead
+// of being looked up from a table so that they work on unbounded boards too.
+func zobristKey(x int, y int, z int, owner PlayerSymbol) uint64 {
+	packed := uint64(x)&0xffffff | (uint64(y)&0xffffff)<<24 | (uint64(z)&0xff)<<48 | uint64(owner)<<56
+	return splitmix64(packed)
+}
+
+// Same as zobristKey but empty cells don't change the hash
+func ownerKey(x int, y int, z int, owner PlayerSymbol) uint64 {
+	if owner == EMPTY {
+		return 0
+	}
+	return zobristKey(x, y, z, owner)
+}
+
+func turnKey(player PlayerSymbol) uint64 {
+	return splitmix64(^uint64(player))
+}
+
+// Changes the hash of the board for the cell being given to the owner
+func (b *Board) rehash(cell BoardCell, owner PlayerSymbol) {
+	b.hash ^= ownerKey(cell.x, cell.y, cell.z, cell.owner) ^ ownerKey(cell.x, cell.y, cell.z, owner)
+}
+
+// Zobrist hash of the stones and blocked cells on the board. Equal positions have equal hashes no
+// matter in which order the stones were placed.
+func (b *Board) Hash() uint64 {
+	return b.hash
+}
+
+// Hash of the whole position including the player to move, eg for transposition tables and for
+// detecting repeated positions
+func (g *GameState) Hash() uint64 {
+	hash := g.Board.Hash() ^ turnKey(g.Turn)
+	for player, count := range g.Captures {
+		if count > 0 {
+			hash ^= splitmix64(uint64(count)<<8 | uint64(player))
+		}
+	}
+	// Local boards are mixed in by their position on the meta board and the target with the key
+	// of an empty cell, which no stone uses
+	for i := range g.LocalBoards {
+		hash ^= splitmix64(g.LocalBoards[i].Hash() + uint64(i))
+	}
+	if g.Target != nil {
+		hash ^= zobristKey(g.Target.X, g.Target.Y, 0, EMPTY)
+	}
+	return hash
+}
@@ -0,0 +1,59 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestBoardHash(t *testing.T) {
+	board := newBoard(15, 15, DefaultWinLength)
+	empty := board.Hash()
+	board.updateCell(7, 7, 0, X)
+	board.updateCell(8, 7, 0, O)
+	board.updateCell(7, 8, 0, X)
+	other := newBoard(15, 15, DefaultWinLength)
+	other.updateCell(7, 8, 0, X)
+	other.updateCell(8, 7, 0, O)
+	other.updateCell(7, 7, 0, X)
+	if board.Hash() != other.Hash() {
+		t.Error("Same stones placed in a different order had different hashes")
+	}
+	clone := board.clone()
+	if clone.Hash() != board.Hash() {
+		t.Error("Clone had a different hash than the board")
+	}
+	other.removeCell(7, 7, 0)
+	other.updateCell(7, 7, 0, O)
+	if board.Hash() == other.Hash() {
+		t.Error("Stones of different owners had the same hash")
+	}
+	for _, c := range []Coord{{7, 7, 0}, {8, 7, 0}, {7, 8, 0}} {
+		board.removeCell(c.X, c.Y, c.Z)
+	}
+	if board.Hash() != empty {
+		t.Error("Removing every stone didn't give back the hash of the empty board")
+	}
+
+	unbounded := newUnboundedBoard(DefaultWinLength)
+	unbounded.updateCell(-1, 0, 0, X)
+	if unbounded.Hash() == newUnboundedBoard(DefaultWinLength).Hash() || zobristKey(-1, 0, 0, X) == zobristKey(0, -1, 0, X) {
+		t.Error("Stones on an unbounded board didn't change the hash")
+	}
+}
+
+func TestGameStateHash(t *testing.T) {
+	game := startGame(t, GameOptions{Size: 15})
+	start := game.State.Hash()
+	game.HandlePlayerTurn(Move{X: 7, Y: 7, Player: X})
+	afterX := game.State.Hash()
+	if afterX == start || afterX == game.State.Board.Hash() {
+		t.Error("Hash of the game didn't include the stones and the player to move")
+	}
+	game.Undo()
+	if game.State.Hash() != start {
+		t.Error("Undo didn't give back the hash of the starting position")
+	}
+	game.Redo()
+	if game.State.Hash() != afterX {
+		t.Error("Redo didn't give back the hash after the first move")
+	}
+}
@@ -1,9 +1,140 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/TeemuKoivisto/tic-tac-5-go/game"
 )
 
 func main() {
-	game.Play()
+	size := flag.Int("size", 5, "width and height of a square board")
+	width := flag.Int("width", 0, "width of the board, overrides size")
+	height := flag.Int("height", 0, "height of the board, overrides size")
+	depth := flag.Int("depth", 1, "number of layers, eg 4 for a 4x4x4 cube")
+	winLength := flag.Int("win", game.DefaultWinLength, "stones in a row needed to win")
+	misere := flag.Bool("misere", false, "making a line of the win length loses instead of wins")
+	noUndo := flag.Bool("no-undo", false, "disable taking back moves")
+	earlyDraw := flag.Bool("early-draw", false, "end the game in a tie once no line can be completed")
+	ruleSet := flag.String("rules", "freestyle", "rule-set for winning lines, eg standard or x_overline_loses")
+	opening := flag.String("opening", "free_opening", "opening rule, eg pro, long_pro, swap or swap2")
+	players := flag.Int("players", 2, "number of players taking turns, up to 4")
+	gravity := flag.Bool("gravity", false, "drop stones to the bottom of the chosen column")
+	torus := flag.Bool("torus", false, "wrap lines around the edges of the board")
+	unbounded := flag.Bool("unbounded", false, "play on a board without edges")
+	ultimate := flag.Bool("ultimate", false, "play on a grid of local boards that are won to claim cells of the meta board")
+	shape := flag.String("mask", "", "shape of the playable area, diamond or cross")
+	ai := flag.Bool("ai", false, "play against the computer, which plays O")
+	aiLevel := flag.String("level", "medium", "strength of the computer, easy, medium or hard")
+	think := flag.Duration("think", 0, "time the computer may spend on a move, eg 2s, overrides the level's limit")
+	flag.Parse()
+	if *unbounded {
+		*width, *height, *depth = 0, 0, 0
+	} else {
+		if *width == 0 {
+			*width = *size
+		}
+		if *height == 0 {
+			*height = *size
+		}
+	}
+	rules, err := parseRuleSet(*ruleSet)
+	if err != nil {
+		fmt.Println("error", err)
+		os.Exit(1)
+	}
+	openingRule, err := parseOpening(*opening)
+	if err != nil {
+		fmt.Println("error", err)
+		os.Exit(1)
+	}
+	level, err := parseAILevel(*aiLevel)
+	if err != nil {
+		fmt.Println("error", err)
+		os.Exit(1)
+	}
+	aiOpts := level.Options()
+	if *think > 0 {
+		aiOpts.TimeLimit = *think
+	}
+	mask, err := parseMask(*shape, *width, *height)
+	if err != nil {
+		fmt.Println("error", err)
+		os.Exit(1)
+	}
+	topology := game.PLANE
+	if *torus {
+		topology = game.TORUS
+	}
+	gameType := game.HOT_SEAT
+	if *ai {
+		gameType = game.LOCAL_AI
+	}
+	game.Play(game.GameOptions{
+		Width:       *width,
+		Height:      *height,
+		Depth:       *depth,
+		WinLength:   *winLength,
+		RuleSet:     rules,
+		GameType:    gameType,
+		Opening:     openingRule,
+		EarlyDraw:   *earlyDraw,
+		Topology:    topology,
+		Unbounded:   *unbounded,
+		Gravity:     *gravity,
+		Players:     *players,
+		Mask:        mask,
+		Ultimate:    *ultimate,
+		Misere:      *misere,
+		DisableUndo: *noUndo,
+		AI:          aiOpts,
+	})
+}
+
+func parseRuleSet(name string) (game.RuleSet, error) {
+	for _, rules := range game.RuleSets {
+		if strings.EqualFold(rules.String(), name) {
+			return rules, nil
+		}
+	}
+	return game.FREESTYLE, fmt.Errorf("unknown rule-set %s", name)
+}
+
+func parseOpening(name string) (game.Opening, error) {
+	for _, opening := range game.Openings {
+		if strings.EqualFold(opening.String(), name) {
+			return opening, nil
+		}
+	}
+	return game.FREE_OPENING, fmt.Errorf("unknown opening %s", name)
+}
+
+func parseAILevel(name string) (game.AILevel, error) {
+	for _, level := range game.AILevels {
+		if strings.EqualFold(level.String(), name) {
+			return level, nil
+		}
+	}
+	return game.MEDIUM, fmt.Errorf("unknown AI level %s", name)
+}
+
+func parseMask(shape string, width int, height int) ([][]bool, error) {
+	switch strings.ToLower(shape) {
+	case "":
+		return nil, nil
+	case "diamond":
+		return game.DiamondMask(width, height), nil
+	case "cross":
+		return game.CrossMask(width, height, (minInt(width, height)+2)/3), nil
+	}
+	return nil, fmt.Errorf("unknown mask %s", shape)
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
 }